@@ -0,0 +1,232 @@
+package decimal
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact base 10 number used for prices and quantities, the zero value is 0
+type Decimal struct {
+	// The value is unscaled / 10^scale, nil means 0
+	unscaled *big.Int
+	scale    int32
+}
+
+var ten = big.NewInt(10)
+
+// Create the decimal unscaled / 10^scale, e.g. New(123, 2) is 1.23
+func New(unscaled int64, scale int32) Decimal {
+	if scale < 0 {
+		return Decimal{unscaled: new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale))}
+	}
+	return Decimal{unscaled: big.NewInt(unscaled), scale: scale}
+}
+
+// Parse a plain decimal string such as "-0.00001" or "100", the exponent notation is rejected
+func Parse(s string) (Decimal, error) {
+	str := s
+	neg := false
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		neg = str[0] == '-'
+		str = str[1:]
+	}
+
+	intPart, fracPart := str, ""
+	if i := strings.IndexByte(str, '.'); i >= 0 {
+		intPart, fracPart = str[:i], str[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	for _, c := range intPart + fracPart {
+		if c < '0' || c > '9' {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+
+	unscaled, ok := new(big.Int).SetString("0"+intPart+fracPart, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	if neg {
+		unscaled.Neg(unscaled)
+	}
+	return Decimal{unscaled: unscaled, scale: int32(len(fracPart))}, nil
+}
+
+// Parse a decimal string, it panics if the string is invalid
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Convert a float64 with the shortest representation which reads back to the same float64,
+// e.g. 1e-05 is 0.00001 and 0.1 is 0.1
+func FromFloat(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}, fmt.Errorf("invalid decimal %v", f)
+	}
+	return Parse(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// Format the decimal without an exponent
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	sign := ""
+	if d.Sign() < 0 {
+		sign = "-"
+	}
+	if d.scale <= 0 {
+		return sign + digits
+	}
+
+	scale := int(d.scale)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+}
+
+// Get -1, 0 or +1 for a negative, zero or positive decimal
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Compare with another decimal, it returns -1, 0 or +1 if d is less than, equal to or greater than o
+func (d Decimal) Cmp(o Decimal) int {
+	a, b, _ := align(d, o)
+	return a.Cmp(b)
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b, scale := align(d, o)
+	return Decimal{unscaled: a.Add(a, b), scale: scale}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b, scale := align(d, o)
+	return Decimal{unscaled: a.Sub(a, b), scale: scale}
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{unscaled: new(big.Int).Mul(d.int(), o.int()), scale: d.scale + o.scale}
+}
+
+// Remove the trailing zeros of the fraction, e.g. 1.2300 is 1.23
+func (d Decimal) Normalize() Decimal {
+	unscaled, scale := new(big.Int).Set(d.int()), d.scale
+	if unscaled.Sign() == 0 {
+		return Decimal{}
+	}
+	q, r := new(big.Int), new(big.Int)
+	for scale > 0 {
+		q.QuoRem(unscaled, ten, r)
+		if r.Sign() != 0 {
+			break
+		}
+		unscaled.Set(q)
+		scale--
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// Round to the nearest multiple of the step, a half step is rounded away from zero.
+// The decimal is returned as it is if the step is not positive.
+func (d Decimal) RoundToStep(step Decimal) Decimal {
+	return d.toStep(step, true)
+}
+
+// Truncate to the multiple of the step toward zero.
+// The decimal is returned as it is if the step is not positive.
+func (d Decimal) TruncateToStep(step Decimal) Decimal {
+	return d.toStep(step, false)
+}
+
+func (d Decimal) toStep(step Decimal, round bool) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+
+	a, s, scale := align(d, step)
+	q, r := new(big.Int).QuoRem(a, s, new(big.Int))
+	if round && new(big.Int).Abs(new(big.Int).Lsh(r, 1)).Cmp(s) >= 0 {
+		q.Add(q, big.NewInt(int64(a.Sign())))
+	}
+
+	// A multiple of the step has no more digits than the step
+	stepScale := step.Normalize().scale
+	unscaled := q.Mul(q, s)
+	unscaled.Quo(unscaled, pow10(scale-stepScale))
+	return Decimal{unscaled: unscaled, scale: stepScale}
+}
+
+// Check whether the decimal is a multiple of the step
+func (d Decimal) IsMultipleOf(step Decimal) bool {
+	if step.Sign() <= 0 {
+		return true
+	}
+	a, s, _ := align(d, step)
+	return new(big.Int).Rem(a, s).Sign() == 0
+}
+
+// Format as a JSON string, the exchange sends prices and quantities as strings
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// Parse a JSON string or number
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+	if string(data) == "null" || len(data) == 0 {
+		*d = Decimal{}
+		return nil
+	}
+	val, err := Parse(string(data))
+	if err != nil {
+		f, ferr := strconv.ParseFloat(string(data), 64)
+		if ferr != nil {
+			return err
+		}
+		if val, err = FromFloat(f); err != nil {
+			return err
+		}
+	}
+	*d = val
+	return nil
+}
+
+// Get the unscaled values of both decimals at the larger scale
+func align(a, b Decimal) (*big.Int, *big.Int, int32) {
+	x, y := new(big.Int).Set(a.int()), new(big.Int).Set(b.int())
+	switch {
+	case a.scale > b.scale:
+		y.Mul(y, pow10(a.scale-b.scale))
+		return x, y, a.scale
+	case b.scale > a.scale:
+		x.Mul(x, pow10(b.scale-a.scale))
+		return x, y, b.scale
+	}
+	return x, y, a.scale
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(ten, big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,101 @@
+package decimal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for s, expected := range map[string]string{
+		"1.11":     "1.11",
+		"-0.00001": "-0.00001",
+		"+100":     "100",
+		".5":       "0.5",
+		"2.":       "2",
+		"0.10":     "0.10",
+	} {
+		d, err := Parse(s)
+		assert.Nil(t, err, s)
+		assert.EqualValues(t, expected, d.String(), s)
+	}
+
+	for _, s := range []string{"", "-", ".", "1e-05", "1.2.3", "abc", "1,000"} {
+		_, err := Parse(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	for f, expected := range map[float64]string{
+		1.11:    "1.11",
+		1e-05:   "0.00001",
+		0.1:     "0.1",
+		10000.5: "10000.5",
+		1e21:    "1000000000000000000000",
+		-2.5e-8: "-0.000000025",
+	} {
+		d, err := FromFloat(f)
+		assert.Nil(t, err)
+		assert.EqualValues(t, expected, d.String())
+	}
+}
+
+func TestNew(t *testing.T) {
+	assert.EqualValues(t, "1.23", New(123, 2).String())
+	assert.EqualValues(t, "12300", New(123, -2).String())
+	assert.EqualValues(t, "0", Decimal{}.String())
+}
+
+func TestCmp(t *testing.T) {
+	assert.EqualValues(t, 0, MustParse("1.10").Cmp(MustParse("1.1")))
+	assert.EqualValues(t, -1, MustParse("0.99").Cmp(MustParse("1")))
+	assert.EqualValues(t, 1, MustParse("-0.5").Cmp(MustParse("-1")))
+	assert.True(t, Decimal{}.IsZero())
+	assert.EqualValues(t, "1.23", MustParse("1.2300").Normalize().String())
+	assert.EqualValues(t, "100", MustParse("100.0").Normalize().String())
+}
+
+func TestRoundToStep(t *testing.T) {
+	tick := MustParse("0.10")
+	assert.EqualValues(t, "1234.5", MustParse("1234.54").RoundToStep(tick).String())
+	assert.EqualValues(t, "1234.6", MustParse("1234.55").RoundToStep(tick).String())
+	assert.EqualValues(t, "-1234.6", MustParse("-1234.55").RoundToStep(tick).String())
+	assert.EqualValues(t, "10.0", MustParse("9.96").RoundToStep(tick).String())
+	assert.EqualValues(t, "1235", MustParse("1234.5").RoundToStep(MustParse("5")).String())
+	assert.EqualValues(t, "1.11", MustParse("1.11").RoundToStep(Decimal{}).String())
+}
+
+func TestTruncateToStep(t *testing.T) {
+	step := MustParse("0.001")
+	assert.EqualValues(t, "0.123", MustParse("0.12399").TruncateToStep(step).String())
+	assert.EqualValues(t, "-0.123", MustParse("-0.12399").TruncateToStep(step).String())
+	assert.EqualValues(t, "0.000", MustParse("0.0009").TruncateToStep(step).String())
+	assert.EqualValues(t, "3", MustParse("3.9").TruncateToStep(MustParse("1")).String())
+	assert.True(t, MustParse("1.25").IsMultipleOf(MustParse("0.05")))
+	assert.False(t, MustParse("1.26").IsMultipleOf(MustParse("0.05")))
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		Price    Decimal `json:"price"`
+		Quantity Decimal `json:"quantity"`
+		Empty    Decimal `json:"empty"`
+	}
+	err := json.Unmarshal([]byte(`{"price":"0.00001","quantity":2.5,"empty":""}`), &v)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "0.00001", v.Price.String())
+	assert.EqualValues(t, "2.5", v.Quantity.String())
+	assert.True(t, v.Empty.IsZero())
+
+	data, err := json.Marshal(v)
+	assert.Nil(t, err)
+	assert.EqualValues(t, `{"price":"0.00001","quantity":"2.5","empty":"0"}`, string(data))
+}
+
+func TestArithmetic(t *testing.T) {
+	assert.EqualValues(t, "1.35", MustParse("1.1").Add(MustParse("0.25")).String())
+	assert.EqualValues(t, "-0.15", MustParse("0.1").Sub(MustParse("0.25")).String())
+	assert.EqualValues(t, "10231.65000", MustParse("9301.5").Mul(MustParse("1.1000")).String())
+}
@@ -0,0 +1,63 @@
+package delivery
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// The maximum length of the prefix of the generated client order ids
+const MaxClientOrderIdPrefix = 16
+
+// The counter wraps around at 6 digits in base 36, so the ids fit in 36 characters
+const clientOrderIdCounter = 36 * 36 * 36 * 36 * 36 * 36
+
+// The client order ids accepted by the exchange
+var clientOrderIdPattern = regexp.MustCompile(`^[\.A-Z\:/a-z0-9_-]{1,36}$`)
+
+// ClientOrderIds generates unique client order ids with a prefix,
+// so a retried order is recognized by the exchange instead of being placed twice
+type ClientOrderIds struct {
+	prefix  string
+	process string
+	counter uint64
+}
+
+// Create a generator, the prefix is at most MaxClientOrderIdPrefix characters of [.A-Z:/a-z0-9_-]
+func NewClientOrderIds(prefix string) (*ClientOrderIds, error) {
+	if err := validClientOrderIdPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	// Tell apart the ids generated by the processes sharing a prefix
+	process := make([]byte, 3)
+	var id string
+	if _, err := rand.Read(process); err == nil {
+		id = hex.EncodeToString(process)
+	} else {
+		id = strconv.FormatInt(int64(os.Getpid()), 36)
+	}
+
+	return &ClientOrderIds{prefix: prefix, process: id}, nil
+}
+
+func validClientOrderIdPrefix(prefix string) error {
+	if len(prefix) > MaxClientOrderIdPrefix {
+		return fmt.Errorf("client order id prefix %q is longer than %d", prefix, MaxClientOrderIdPrefix)
+	}
+	if prefix != "" && !clientOrderIdPattern.MatchString(prefix) {
+		return fmt.Errorf("client order id prefix %q contains invalid characters", prefix)
+	}
+	return nil
+}
+
+// Get the next id: the prefix, the time in base 36, the process and the counter
+func (g *ClientOrderIds) Next() string {
+	n := atomic.AddUint64(&g.counter, 1) % clientOrderIdCounter
+	return g.prefix + strconv.FormatInt(time.Now().UnixMilli(), 36) + g.process + strconv.FormatUint(n, 36)
+}
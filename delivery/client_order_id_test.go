@@ -0,0 +1,43 @@
+package delivery
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/h9896/bingo/mocks"
+	"github.com/h9896/bingo/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientOrderIds(t *testing.T) {
+	ids, err := NewClientOrderIds("bot-1_")
+	assert.Nil(t, err)
+
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		id := ids.Next()
+		assert.True(t, strings.HasPrefix(id, "bot-1_"))
+		assert.True(t, clientOrderIdPattern.MatchString(id), id)
+		assert.False(t, seen[id], id)
+		seen[id] = true
+	}
+
+	// The longest prefix still fits in the limit of the exchange
+	ids, err = NewClientOrderIds(strings.Repeat("a", MaxClientOrderIdPrefix))
+	assert.Nil(t, err)
+	assert.True(t, clientOrderIdPattern.MatchString(ids.Next()))
+}
+
+func TestClientOrderIdsInvalidPrefix(t *testing.T) {
+	_, err := NewClientOrderIds(strings.Repeat("a", MaxClientOrderIdPrefix+1))
+	assert.NotNil(t, err)
+
+	_, err = NewClientOrderIds("bot 1")
+	assert.NotNil(t, err)
+
+	_, err = NewConfig(WithDomain(mocks.MockDomain, true), WithAPIKey(mocks.MockApiKey),
+		WithSigner(rpc.NewHMACSigner(mocks.MockSecret)), WithClientOrderIdPrefix("bot#1"))
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "client order id prefix")
+}
@@ -1,24 +1,66 @@
 package delivery
 
+import (
+	"fmt"
+	"strings"
+)
+
+// The API prefix of the COIN-M futures entry points, the prefix of a profile replaces it
+const DefaultAPIPrefix = "dapi/v1"
+
+// Build the endpoint of an entry point on a domain, the DefaultAPIPrefix of the entry point is replaced
+// by the API prefix when it is set, e.g. to reach the REST API through a proxy
+func Endpoint(domain, apiPrefix, entryPoint string) string {
+	if apiPrefix != "" && strings.HasPrefix(entryPoint, DefaultAPIPrefix+"/") {
+		entryPoint = apiPrefix + strings.TrimPrefix(entryPoint, DefaultAPIPrefix)
+	}
+	return fmt.Sprintf("%s/%s", domain, entryPoint)
+}
+
 const (
-	EntryPointPositionMode       = "dapi/v1/positionSide/dual"
-	EntryPointOrder              = "dapi/v1/order"
-	EntryPointMultipleOrders     = "dapi/v1/batchOrders"
-	EntryPointAllOpenOrders      = "dapi/v1/allOpenOrders"
-	EntryPointCountdownCancelAll = "dapi/v1/countdownCancelAll"
-	EntryPointLeverage           = "dapi/v1/leverage"
-	EntryPointMarginType         = "dapi/v1/marginType"
-	EntryPointPositionMargin     = "dapi/v1/positionMargin"
-	EntryPointOrderAmendment     = "dapi/v1/orderAmendment"
-	EntryPointOpenOrder          = "dapi/v1/openOrder"
-	EntryPointOpenOrders         = "dapi/v1/openOrders"
-	EntryPointAllOrders          = "dapi/v1/allOrders"
-	EntryPointBalance            = "dapi/v1/balance"
-	EntryPointPositionRisk       = "dapi/v1/positionRisk"
-	EntryPointAccount            = "dapi/v1/account"
-	EntryPointUserTrades         = "dapi/v1/userTrades"
-	EntryPointIncome             = "dapi/v1/income"
-	EntryPointLeverageBracket    = "dapi/v1/leverageBracket"
-	EntryPointLeverageBracketV2  = "dapi/v2/leverageBracket"
-	History                      = "history"
+	EntryPointPositionMode          = "dapi/v1/positionSide/dual"
+	EntryPointOrder                 = "dapi/v1/order"
+	EntryPointMultipleOrders        = "dapi/v1/batchOrders"
+	EntryPointAllOpenOrders         = "dapi/v1/allOpenOrders"
+	EntryPointCountdownCancelAll    = "dapi/v1/countdownCancelAll"
+	EntryPointLeverage              = "dapi/v1/leverage"
+	EntryPointMarginType            = "dapi/v1/marginType"
+	EntryPointPositionMargin        = "dapi/v1/positionMargin"
+	EntryPointOrderAmendment        = "dapi/v1/orderAmendment"
+	EntryPointOpenOrder             = "dapi/v1/openOrder"
+	EntryPointOpenOrders            = "dapi/v1/openOrders"
+	EntryPointAllOrders             = "dapi/v1/allOrders"
+	EntryPointBalance               = "dapi/v1/balance"
+	EntryPointPositionRisk          = "dapi/v1/positionRisk"
+	EntryPointAccount               = "dapi/v1/account"
+	EntryPointUserTrades            = "dapi/v1/userTrades"
+	EntryPointIncome                = "dapi/v1/income"
+	EntryPointLeverageBracket       = "dapi/v1/leverageBracket"
+	EntryPointLeverageBracketV2     = "dapi/v2/leverageBracket"
+	EntryPointServerTime            = "dapi/v1/time"
+	EntryPointOrderBook             = "dapi/v1/depth"
+	EntryPointExchangeInfo          = "dapi/v1/exchangeInfo"
+	EntryPointPing                  = "dapi/v1/ping"
+	EntryPointRecentTrades          = "dapi/v1/trades"
+	EntryPointHistoricalTrades      = "dapi/v1/historicalTrades"
+	EntryPointAggTrades             = "dapi/v1/aggTrades"
+	EntryPointPremiumIndex          = "dapi/v1/premiumIndex"
+	EntryPointFundingRate           = "dapi/v1/fundingRate"
+	EntryPointKlines                = "dapi/v1/klines"
+	EntryPointContinuousKlines      = "dapi/v1/continuousKlines"
+	EntryPointIndexPriceKlines      = "dapi/v1/indexPriceKlines"
+	EntryPointMarkPriceKlines       = "dapi/v1/markPriceKlines"
+	EntryPointPremiumIndexKlines    = "dapi/v1/premiumIndexKlines"
+	EntryPointTicker24hr            = "dapi/v1/ticker/24hr"
+	EntryPointTickerPrice           = "dapi/v1/ticker/price"
+	EntryPointBookTicker            = "dapi/v1/ticker/bookTicker"
+	EntryPointOpenInterest          = "dapi/v1/openInterest"
+	EntryPointListenKey             = "dapi/v1/listenKey"
+	EntryPointOpenInterestHist      = "futures/data/openInterestHist"
+	EntryPointTopLongShortAccount   = "futures/data/topLongShortAccountRatio"
+	EntryPointTopLongShortPosition  = "futures/data/topLongShortPositionRatio"
+	EntryPointGlobalLongShort       = "futures/data/globalLongShortAccountRatio"
+	EntryPointTakerBuySellVol       = "futures/data/takerBuySellVol"
+	EntryPointPositionMarginHistory = EntryPointPositionMargin + "/" + History
+	History                         = "history"
 )
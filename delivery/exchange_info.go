@@ -0,0 +1,112 @@
+package delivery
+
+import (
+	"context"
+
+	"github.com/h9896/bingo/decimal"
+	"github.com/h9896/bingo/rpc"
+)
+
+const (
+	FilterPrice         = "PRICE_FILTER"
+	FilterLotSize       = "LOT_SIZE"
+	FilterMarketLotSize = "MARKET_LOT_SIZE"
+	FilterMaxNumOrders  = "MAX_NUM_ORDERS"
+	FilterPercentPrice  = "PERCENT_PRICE"
+)
+
+// Exchange trading rules and symbol information
+type ExchangeInfo struct {
+	Timezone   string       `json:"timezone"`
+	ServerTime int64        `json:"serverTime"`
+	RateLimits []RateLimit  `json:"rateLimits"`
+	Symbols    []SymbolInfo `json:"symbols"`
+}
+
+type RateLimit struct {
+	RateLimitType string `json:"rateLimitType"`
+	Interval      string `json:"interval"`
+	IntervalNum   int64  `json:"intervalNum"`
+	Limit         int64  `json:"limit"`
+}
+
+type SymbolInfo struct {
+	Symbol                string          `json:"symbol"`
+	Pair                  string          `json:"pair"`
+	ContractType          string          `json:"contractType"`
+	DeliveryDate          int64           `json:"deliveryDate"`
+	OnboardDate           int64           `json:"onboardDate"`
+	ContractStatus        string          `json:"contractStatus"`
+	ContractSize          int64           `json:"contractSize"`
+	QuoteAsset            string          `json:"quoteAsset"`
+	BaseAsset             string          `json:"baseAsset"`
+	MarginAsset           string          `json:"marginAsset"`
+	PricePrecision        int32           `json:"pricePrecision"`
+	QuantityPrecision     int32           `json:"quantityPrecision"`
+	BaseAssetPrecision    int32           `json:"baseAssetPrecision"`
+	QuotePrecision        int32           `json:"quotePrecision"`
+	EqualQtyPrecision     int32           `json:"equalQtyPrecision"`
+	TriggerProtect        decimal.Decimal `json:"triggerProtect"`
+	MaintMarginPercent    decimal.Decimal `json:"maintMarginPercent"`
+	RequiredMarginPercent decimal.Decimal `json:"requiredMarginPercent"`
+	LiquidationFee        decimal.Decimal `json:"liquidationFee"`
+	MarketTakeBound       decimal.Decimal `json:"marketTakeBound"`
+	UnderlyingType        string          `json:"underlyingType"`
+	UnderlyingSubType     []string        `json:"underlyingSubType"`
+	OrderTypes            []string        `json:"OrderType"`
+	TimeInForce           []string        `json:"timeInForce"`
+	Filters               []Filter        `json:"filters"`
+}
+
+// Filter of a symbol, only the fields of its filter type are set
+type Filter struct {
+	FilterType string `json:"filterType"`
+
+	// PRICE_FILTER
+	MinPrice decimal.Decimal `json:"minPrice"`
+	MaxPrice decimal.Decimal `json:"maxPrice"`
+	TickSize decimal.Decimal `json:"tickSize"`
+
+	// LOT_SIZE and MARKET_LOT_SIZE
+	MinQty   decimal.Decimal `json:"minQty"`
+	MaxQty   decimal.Decimal `json:"maxQty"`
+	StepSize decimal.Decimal `json:"stepSize"`
+
+	// MAX_NUM_ORDERS
+	Limit int64 `json:"limit"`
+
+	// PERCENT_PRICE
+	MultiplierUp      decimal.Decimal `json:"multiplierUp"`
+	MultiplierDown    decimal.Decimal `json:"multiplierDown"`
+	MultiplierDecimal decimal.Decimal `json:"multiplierDecimal"`
+}
+
+// Get the filter of a type
+func (s SymbolInfo) Filter(filterType string) (Filter, bool) {
+	for _, f := range s.Filters {
+		if f.FilterType == filterType {
+			return f, true
+		}
+	}
+	return Filter{}, false
+}
+
+// Get the precision of the prices and quantities from the PRICE_FILTER and LOT_SIZE filters
+func (s SymbolInfo) Precision() Precision {
+	var p Precision
+	if f, ok := s.Filter(FilterPrice); ok {
+		p.TickSize = f.TickSize
+	}
+	if f, ok := s.Filter(FilterLotSize); ok {
+		p.StepSize = f.StepSize
+	}
+	return p
+}
+
+// Get the current exchange trading rules and symbol information
+func GetExchangeInfo(ctx context.Context, c rpc.GenericHttpClient, domain, apiPrefix string) (*ExchangeInfo, error) {
+	endpoint := Endpoint(domain, apiPrefix, EntryPointExchangeInfo)
+	req := c.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"), Weight(EntryPointExchangeInfo))
+
+	return rpc.DoJSON[*ExchangeInfo](ctx, c, req)
+}
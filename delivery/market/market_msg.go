@@ -0,0 +1,251 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/h9896/bingo/decimal"
+)
+
+type OrderBookRequest struct {
+	Symbol string
+	// Valid limits are 5, 10, 20, 50, 100, 500 and 1000, the default is 500
+	Limit int64
+}
+
+type TradesRequest struct {
+	Symbol string
+	// The default is 500, the maximum is 1000
+	Limit int64
+	// Trade id to fetch from, only used by the historical trades
+	FromId int64
+}
+
+type AggregateTradesRequest struct {
+	Symbol    string
+	FromId    int64
+	StartTime int64
+	EndTime   int64
+	Limit     int64
+}
+
+// Filter of the symbol or the pair, both are optional
+type SymbolRequest struct {
+	Symbol string
+	Pair   string
+}
+
+type FundingRateRequest struct {
+	Symbol    string
+	StartTime int64
+	EndTime   int64
+	Limit     int64
+}
+
+type KlinesRequest struct {
+	// Symbol of the klines, mark price klines and premium index klines
+	Symbol string
+	// Pair of the continuous contract klines and index price klines
+	Pair string
+	// Contract type of the continuous contract klines, e.g. PERPETUAL, CURRENT_QUARTER, NEXT_QUARTER
+	ContractType string
+	Interval     string
+	StartTime    int64
+	EndTime      int64
+	Limit        int64
+}
+
+// Request of the statistics under futures/data
+type DataRequest struct {
+	Pair string
+	// Contract type of the open interest and the taker buy/sell volume, e.g. ALL, PERPETUAL
+	ContractType string
+	// Period of the statistics, e.g. 5m, 1h, 1d
+	Period    string
+	StartTime int64
+	EndTime   int64
+	Limit     int64
+}
+
+type ServerTime struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
+// Price level of an order book, sent as ["price", "quantity"]
+type PriceLevel struct {
+	Price    decimal.Decimal
+	Quantity decimal.Decimal
+}
+
+func (l *PriceLevel) UnmarshalJSON(data []byte) error {
+	var fields []decimal.Decimal
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) < 2 {
+		return fmt.Errorf("invalid price level %s", data)
+	}
+	l.Price, l.Quantity = fields[0], fields[1]
+	return nil
+}
+
+type OrderBook struct {
+	LastUpdateId    int64        `json:"lastUpdateId"`
+	Symbol          string       `json:"symbol"`
+	Pair            string       `json:"pair"`
+	MessageTime     int64        `json:"E"`
+	TransactionTime int64        `json:"T"`
+	Bids            []PriceLevel `json:"bids"`
+	Asks            []PriceLevel `json:"asks"`
+}
+
+type Trade struct {
+	Id           int64           `json:"id"`
+	Price        decimal.Decimal `json:"price"`
+	Qty          decimal.Decimal `json:"qty"`
+	BaseQty      decimal.Decimal `json:"baseQty"`
+	Time         int64           `json:"time"`
+	IsBuyerMaker bool            `json:"isBuyerMaker"`
+}
+
+type AggregateTrade struct {
+	AggregateTradeId int64           `json:"a"`
+	Price            decimal.Decimal `json:"p"`
+	Quantity         decimal.Decimal `json:"q"`
+	FirstTradeId     int64           `json:"f"`
+	LastTradeId      int64           `json:"l"`
+	Timestamp        int64           `json:"T"`
+	IsBuyerMaker     bool            `json:"m"`
+}
+
+// Mark price, index price and funding rate of a symbol
+type PremiumIndex struct {
+	Symbol               string          `json:"symbol"`
+	Pair                 string          `json:"pair"`
+	MarkPrice            decimal.Decimal `json:"markPrice"`
+	IndexPrice           decimal.Decimal `json:"indexPrice"`
+	EstimatedSettlePrice decimal.Decimal `json:"estimatedSettlePrice"`
+	LastFundingRate      decimal.Decimal `json:"lastFundingRate"`
+	InterestRate         decimal.Decimal `json:"interestRate"`
+	NextFundingTime      int64           `json:"nextFundingTime"`
+	Time                 int64           `json:"time"`
+}
+
+type FundingRate struct {
+	Symbol      string          `json:"symbol"`
+	FundingTime int64           `json:"fundingTime"`
+	FundingRate decimal.Decimal `json:"fundingRate"`
+}
+
+// Kline sent as an array, the index price, mark price and premium index klines leave the volumes zero
+type Kline struct {
+	OpenTime                int64
+	Open                    decimal.Decimal
+	High                    decimal.Decimal
+	Low                     decimal.Decimal
+	Close                   decimal.Decimal
+	Volume                  decimal.Decimal
+	CloseTime               int64
+	BaseAssetVolume         decimal.Decimal
+	NumberOfTrades          int64
+	TakerBuyVolume          decimal.Decimal
+	TakerBuyBaseAssetVolume decimal.Decimal
+}
+
+func (k *Kline) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if len(fields) < 11 {
+		return fmt.Errorf("invalid kline %s", data)
+	}
+
+	targets := []interface{}{&k.OpenTime, &k.Open, &k.High, &k.Low, &k.Close, &k.Volume,
+		&k.CloseTime, &k.BaseAssetVolume, &k.NumberOfTrades, &k.TakerBuyVolume, &k.TakerBuyBaseAssetVolume}
+	for i, target := range targets {
+		if err := json.Unmarshal(fields[i], target); err != nil {
+			return fmt.Errorf("invalid kline field %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+type Ticker24hr struct {
+	Symbol             string          `json:"symbol"`
+	Pair               string          `json:"pair"`
+	PriceChange        decimal.Decimal `json:"priceChange"`
+	PriceChangePercent decimal.Decimal `json:"priceChangePercent"`
+	WeightedAvgPrice   decimal.Decimal `json:"weightedAvgPrice"`
+	LastPrice          decimal.Decimal `json:"lastPrice"`
+	LastQty            decimal.Decimal `json:"lastQty"`
+	OpenPrice          decimal.Decimal `json:"openPrice"`
+	HighPrice          decimal.Decimal `json:"highPrice"`
+	LowPrice           decimal.Decimal `json:"lowPrice"`
+	Volume             decimal.Decimal `json:"volume"`
+	BaseVolume         decimal.Decimal `json:"baseVolume"`
+	OpenTime           int64           `json:"openTime"`
+	CloseTime          int64           `json:"closeTime"`
+	FirstId            int64           `json:"firstId"`
+	LastId             int64           `json:"lastId"`
+	Count              int64           `json:"count"`
+}
+
+type PriceTicker struct {
+	Symbol string          `json:"symbol"`
+	Pair   string          `json:"ps"`
+	Price  decimal.Decimal `json:"price"`
+	Time   int64           `json:"time"`
+}
+
+type BookTicker struct {
+	Symbol   string          `json:"symbol"`
+	Pair     string          `json:"pair"`
+	BidPrice decimal.Decimal `json:"bidPrice"`
+	BidQty   decimal.Decimal `json:"bidQty"`
+	AskPrice decimal.Decimal `json:"askPrice"`
+	AskQty   decimal.Decimal `json:"askQty"`
+	Time     int64           `json:"time"`
+}
+
+type OpenInterest struct {
+	Symbol       string          `json:"symbol"`
+	Pair         string          `json:"pair"`
+	OpenInterest decimal.Decimal `json:"openInterest"`
+	ContractType string          `json:"contractType"`
+	Time         int64           `json:"time"`
+}
+
+type OpenInterestHistory struct {
+	Pair                 string          `json:"pair"`
+	ContractType         string          `json:"contractType"`
+	SumOpenInterest      decimal.Decimal `json:"sumOpenInterest"`
+	SumOpenInterestValue decimal.Decimal `json:"sumOpenInterestValue"`
+	Timestamp            int64           `json:"timestamp"`
+}
+
+type LongShortAccountRatio struct {
+	Pair           string          `json:"pair"`
+	LongShortRatio decimal.Decimal `json:"longShortRatio"`
+	LongAccount    decimal.Decimal `json:"longAccount"`
+	ShortAccount   decimal.Decimal `json:"shortAccount"`
+	Timestamp      int64           `json:"timestamp"`
+}
+
+type LongShortPositionRatio struct {
+	Pair           string          `json:"pair"`
+	LongShortRatio decimal.Decimal `json:"longShortRatio"`
+	LongPosition   decimal.Decimal `json:"longPosition"`
+	ShortPosition  decimal.Decimal `json:"shortPosition"`
+	Timestamp      int64           `json:"timestamp"`
+}
+
+type TakerBuySellVolume struct {
+	Pair              string          `json:"pair"`
+	ContractType      string          `json:"contractType"`
+	TakerBuyVol       decimal.Decimal `json:"takerBuyVol"`
+	TakerSellVol      decimal.Decimal `json:"takerSellVol"`
+	TakerBuyVolValue  decimal.Decimal `json:"takerBuyVolValue"`
+	TakerSellVolValue decimal.Decimal `json:"takerSellVolValue"`
+	Timestamp         int64           `json:"timestamp"`
+}
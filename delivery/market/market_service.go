@@ -0,0 +1,240 @@
+package market
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/h9896/bingo/delivery"
+	"github.com/h9896/bingo/profile"
+	"github.com/h9896/bingo/rpc"
+)
+
+// DeliveryMarketService covers the public market data endpoints of COIN-M futures
+type DeliveryMarketService interface {
+	// Test connectivity to the REST API
+	Ping(ctx context.Context) error
+	// Get the current server time in milliseconds
+	ServerTime(ctx context.Context) (int64, error)
+	// Get the current exchange trading rules and symbol information
+	ExchangeInfo(ctx context.Context) (*delivery.ExchangeInfo, error)
+	OrderBook(ctx context.Context, request *OrderBookRequest) (*OrderBook, error)
+	RecentTrades(ctx context.Context, request *TradesRequest) ([]*Trade, error)
+	// Get older trades, the api key is required
+	HistoricalTrades(ctx context.Context, request *TradesRequest) ([]*Trade, error)
+	// Get compressed trades, the trades filled at the same time, from the same order, with the same price are aggregated
+	AggregateTrades(ctx context.Context, request *AggregateTradesRequest) ([]*AggregateTrade, error)
+	// Get the index price, mark price and funding rate
+	PremiumIndex(ctx context.Context, request *SymbolRequest) ([]*PremiumIndex, error)
+	FundingRateHistory(ctx context.Context, request *FundingRateRequest) ([]*FundingRate, error)
+	Klines(ctx context.Context, request *KlinesRequest) ([]*Kline, error)
+	ContinuousKlines(ctx context.Context, request *KlinesRequest) ([]*Kline, error)
+	IndexPriceKlines(ctx context.Context, request *KlinesRequest) ([]*Kline, error)
+	MarkPriceKlines(ctx context.Context, request *KlinesRequest) ([]*Kline, error)
+	PremiumIndexKlines(ctx context.Context, request *KlinesRequest) ([]*Kline, error)
+	// Get the 24 hour rolling window price change statistics
+	Ticker24hr(ctx context.Context, request *SymbolRequest) ([]*Ticker24hr, error)
+	PriceTicker(ctx context.Context, request *SymbolRequest) ([]*PriceTicker, error)
+	// Get the best price and quantity on the order book
+	BookTicker(ctx context.Context, request *SymbolRequest) ([]*BookTicker, error)
+	OpenInterest(ctx context.Context, symbol string) (*OpenInterest, error)
+	OpenInterestHistory(ctx context.Context, request *DataRequest) ([]*OpenInterestHistory, error)
+	TopLongShortAccountRatio(ctx context.Context, request *DataRequest) ([]*LongShortAccountRatio, error)
+	TopLongShortPositionRatio(ctx context.Context, request *DataRequest) ([]*LongShortPositionRatio, error)
+	GlobalLongShortAccountRatio(ctx context.Context, request *DataRequest) ([]*LongShortAccountRatio, error)
+	TakerBuySellVolume(ctx context.Context, request *DataRequest) ([]*TakerBuySellVolume, error)
+}
+
+type deliveryMarketService struct {
+	httpclient rpc.GenericHttpClient
+	domain     string
+	apiPrefix  string
+}
+
+// Create the service, the api key is only used by the historical trades
+func NewDeliveryMarketService(domain, apikey string, useSSL bool, client rpc.HTTPClient, opts ...rpc.ClientOption) DeliveryMarketService {
+	return &deliveryMarketService{
+		httpclient: rpc.NewGenericHttpClient(apikey, useSSL, client, opts...),
+		domain:     domain,
+	}
+}
+
+// Create the service with the COIN-M futures endpoints of a profile
+func NewDeliveryMarketServiceWithProfile(p profile.Profile, apikey string, client rpc.HTTPClient, opts ...rpc.ClientOption) DeliveryMarketService {
+	return &deliveryMarketService{
+		httpclient: rpc.NewGenericHttpClient(apikey, p.CoinM.UseSSL, client, opts...),
+		domain:     p.CoinM.RestDomain,
+		apiPrefix:  p.CoinM.APIPrefix,
+	}
+}
+
+// Create the service with the options, the api key and the signer are optional
+func NewDeliveryMarketServiceWithOptions(opts ...delivery.Option) (DeliveryMarketService, error) {
+	cfg, err := delivery.NewPublicConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deliveryMarketService{
+		httpclient: cfg.NewHttpClient(),
+		domain:     cfg.Domain,
+		apiPrefix:  cfg.APIPrefix,
+	}, nil
+}
+
+// Parameters of a request, the empty values are left out
+type params []*rpc.HttpParameter
+
+func (p params) add(key, val string) params {
+	if val == "" {
+		return p
+	}
+	return append(p, &rpc.HttpParameter{Key: key, Val: val})
+}
+
+func (p params) addInt(key string, val int64) params {
+	if val == 0 {
+		return p
+	}
+	return append(p, &rpc.HttpParameter{Key: key, Val: strconv.FormatInt(val, 10)})
+}
+
+// Get the endpoint of an entry point with the API prefix of the service
+func (s *deliveryMarketService) endpoint(entryPoint string) string {
+	return delivery.Endpoint(s.domain, s.apiPrefix, entryPoint)
+}
+
+// Send a GET request to an entry point and decode the response
+func get[T any](ctx context.Context, s *deliveryMarketService, entryPoint string, p params, opts ...rpc.RequestOption) (T, error) {
+	endpoint := s.endpoint(entryPoint)
+	options := []rpc.RequestOption{rpc.SetEndpoint(endpoint), rpc.SetMethod("get")}
+	if len(p) > 0 {
+		options = append(options, rpc.SetParams(p...))
+	}
+	options = append(options, opts...)
+	options = append(options, delivery.Weight(entryPoint))
+
+	req := s.httpclient.GetHttpRequest(options...)
+	return rpc.DoJSON[T](ctx, s.httpclient, req)
+}
+
+func (s *deliveryMarketService) Ping(ctx context.Context) error {
+	_, err := get[struct{}](ctx, s, delivery.EntryPointPing, nil)
+	return err
+}
+
+func (s *deliveryMarketService) ServerTime(ctx context.Context) (int64, error) {
+	resp, err := get[ServerTime](ctx, s, delivery.EntryPointServerTime, nil)
+	return resp.ServerTime, err
+}
+
+func (s *deliveryMarketService) ExchangeInfo(ctx context.Context) (*delivery.ExchangeInfo, error) {
+	return delivery.GetExchangeInfo(ctx, s.httpclient, s.domain, s.apiPrefix)
+}
+
+func (s *deliveryMarketService) OrderBook(ctx context.Context, request *OrderBookRequest) (*OrderBook, error) {
+	p := params{}.add("symbol", request.Symbol).addInt("limit", request.Limit)
+	return get[*OrderBook](ctx, s, delivery.EntryPointOrderBook, p)
+}
+
+func (s *deliveryMarketService) RecentTrades(ctx context.Context, request *TradesRequest) ([]*Trade, error) {
+	p := params{}.add("symbol", request.Symbol).addInt("limit", request.Limit)
+	return get[[]*Trade](ctx, s, delivery.EntryPointRecentTrades, p)
+}
+
+func (s *deliveryMarketService) HistoricalTrades(ctx context.Context, request *TradesRequest) ([]*Trade, error) {
+	p := params{}.add("symbol", request.Symbol).addInt("limit", request.Limit).addInt("fromId", request.FromId)
+	return get[[]*Trade](ctx, s, delivery.EntryPointHistoricalTrades, p, rpc.SetPrivate())
+}
+
+func (s *deliveryMarketService) AggregateTrades(ctx context.Context, request *AggregateTradesRequest) ([]*AggregateTrade, error) {
+	p := params{}.add("symbol", request.Symbol).addInt("fromId", request.FromId).
+		addInt("startTime", request.StartTime).addInt("endTime", request.EndTime).addInt("limit", request.Limit)
+	return get[[]*AggregateTrade](ctx, s, delivery.EntryPointAggTrades, p)
+}
+
+func (s *deliveryMarketService) PremiumIndex(ctx context.Context, request *SymbolRequest) ([]*PremiumIndex, error) {
+	p := params{}.add("symbol", request.Symbol).add("pair", request.Pair)
+	return get[[]*PremiumIndex](ctx, s, delivery.EntryPointPremiumIndex, p)
+}
+
+func (s *deliveryMarketService) FundingRateHistory(ctx context.Context, request *FundingRateRequest) ([]*FundingRate, error) {
+	p := params{}.add("symbol", request.Symbol).
+		addInt("startTime", request.StartTime).addInt("endTime", request.EndTime).addInt("limit", request.Limit)
+	return get[[]*FundingRate](ctx, s, delivery.EntryPointFundingRate, p)
+}
+
+func (s *deliveryMarketService) klines(ctx context.Context, entryPoint string, request *KlinesRequest) ([]*Kline, error) {
+	p := params{}.add("symbol", request.Symbol).add("pair", request.Pair).add("contractType", request.ContractType).
+		add("interval", request.Interval).addInt("startTime", request.StartTime).addInt("endTime", request.EndTime).addInt("limit", request.Limit)
+	return get[[]*Kline](ctx, s, entryPoint, p)
+}
+
+// Get the klines of a symbol
+func (s *deliveryMarketService) Klines(ctx context.Context, request *KlinesRequest) ([]*Kline, error) {
+	return s.klines(ctx, delivery.EntryPointKlines, request)
+}
+
+// Get the klines of a pair and a contract type
+func (s *deliveryMarketService) ContinuousKlines(ctx context.Context, request *KlinesRequest) ([]*Kline, error) {
+	return s.klines(ctx, delivery.EntryPointContinuousKlines, request)
+}
+
+// Get the index price klines of a pair
+func (s *deliveryMarketService) IndexPriceKlines(ctx context.Context, request *KlinesRequest) ([]*Kline, error) {
+	return s.klines(ctx, delivery.EntryPointIndexPriceKlines, request)
+}
+
+// Get the mark price klines of a symbol
+func (s *deliveryMarketService) MarkPriceKlines(ctx context.Context, request *KlinesRequest) ([]*Kline, error) {
+	return s.klines(ctx, delivery.EntryPointMarkPriceKlines, request)
+}
+
+// Get the premium index klines of a symbol
+func (s *deliveryMarketService) PremiumIndexKlines(ctx context.Context, request *KlinesRequest) ([]*Kline, error) {
+	return s.klines(ctx, delivery.EntryPointPremiumIndexKlines, request)
+}
+
+func (s *deliveryMarketService) Ticker24hr(ctx context.Context, request *SymbolRequest) ([]*Ticker24hr, error) {
+	p := params{}.add("symbol", request.Symbol).add("pair", request.Pair)
+	return get[[]*Ticker24hr](ctx, s, delivery.EntryPointTicker24hr, p)
+}
+
+func (s *deliveryMarketService) PriceTicker(ctx context.Context, request *SymbolRequest) ([]*PriceTicker, error) {
+	p := params{}.add("symbol", request.Symbol).add("pair", request.Pair)
+	return get[[]*PriceTicker](ctx, s, delivery.EntryPointTickerPrice, p)
+}
+
+func (s *deliveryMarketService) BookTicker(ctx context.Context, request *SymbolRequest) ([]*BookTicker, error) {
+	p := params{}.add("symbol", request.Symbol).add("pair", request.Pair)
+	return get[[]*BookTicker](ctx, s, delivery.EntryPointBookTicker, p)
+}
+
+func (s *deliveryMarketService) OpenInterest(ctx context.Context, symbol string) (*OpenInterest, error) {
+	p := params{}.add("symbol", symbol)
+	return get[*OpenInterest](ctx, s, delivery.EntryPointOpenInterest, p)
+}
+
+func (p params) data(request *DataRequest) params {
+	return p.add("pair", request.Pair).add("contractType", request.ContractType).add("period", request.Period).
+		addInt("startTime", request.StartTime).addInt("endTime", request.EndTime).addInt("limit", request.Limit)
+}
+
+func (s *deliveryMarketService) OpenInterestHistory(ctx context.Context, request *DataRequest) ([]*OpenInterestHistory, error) {
+	return get[[]*OpenInterestHistory](ctx, s, delivery.EntryPointOpenInterestHist, params{}.data(request))
+}
+
+func (s *deliveryMarketService) TopLongShortAccountRatio(ctx context.Context, request *DataRequest) ([]*LongShortAccountRatio, error) {
+	return get[[]*LongShortAccountRatio](ctx, s, delivery.EntryPointTopLongShortAccount, params{}.data(request))
+}
+
+func (s *deliveryMarketService) TopLongShortPositionRatio(ctx context.Context, request *DataRequest) ([]*LongShortPositionRatio, error) {
+	return get[[]*LongShortPositionRatio](ctx, s, delivery.EntryPointTopLongShortPosition, params{}.data(request))
+}
+
+func (s *deliveryMarketService) GlobalLongShortAccountRatio(ctx context.Context, request *DataRequest) ([]*LongShortAccountRatio, error) {
+	return get[[]*LongShortAccountRatio](ctx, s, delivery.EntryPointGlobalLongShort, params{}.data(request))
+}
+
+func (s *deliveryMarketService) TakerBuySellVolume(ctx context.Context, request *DataRequest) ([]*TakerBuySellVolume, error) {
+	return get[[]*TakerBuySellVolume](ctx, s, delivery.EntryPointTakerBuySellVol, params{}.data(request))
+}
@@ -0,0 +1,292 @@
+package market
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/h9896/bingo/delivery"
+	"github.com/h9896/bingo/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func getMockDeliveryMarketService() DeliveryMarketService {
+	return NewDeliveryMarketService(mocks.MockDomain, mocks.MockApiKey, true, &mocks.MockHTTPClient{})
+}
+
+func mockResponse(t *testing.T, path string, query map[string]string, data string) {
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.EqualValues(t, http.MethodGet, req.Method)
+		assert.EqualValues(t, mocks.MockDomain, req.URL.Host)
+		assert.EqualValues(t, path, req.URL.Path)
+		params := req.URL.Query()
+		assert.Len(t, params, len(query))
+		for key, val := range query {
+			assert.EqualValues(t, val, params.Get(key), key)
+		}
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+}
+
+func TestPing(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/ping", nil, `{}`)
+	assert.Nil(t, service.Ping(context.Background()))
+}
+
+func TestServerTime(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/time", nil, `{"serverTime": 1499827319559}`)
+	serverTime, err := service.ServerTime(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1499827319559, serverTime)
+}
+
+func TestExchangeInfo(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/exchangeInfo", nil, `{
+		"timezone": "UTC",
+		"serverTime": 1565613908500,
+		"symbols": [{
+			"symbol": "BTCUSD_200925",
+			"filters": [{"filterType": "PRICE_FILTER", "maxPrice": "100000", "minPrice": "0.1", "tickSize": "0.1"}]
+		}]
+	}`)
+	info, err := service.ExchangeInfo(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, "BTCUSD_200925", info.Symbols[0].Symbol)
+	f, ok := info.Symbols[0].Filter(delivery.FilterPrice)
+	assert.True(t, ok)
+	assert.EqualValues(t, "0.1", f.TickSize.String())
+}
+
+func TestOrderBook(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/depth", map[string]string{"symbol": "BTCUSD_PERP", "limit": "5"}, `{
+		"lastUpdateId": 16769853,
+		"symbol": "BTCUSD_PERP",
+		"pair": "BTCUSD",
+		"E": 1591250106370,
+		"T": 1591250106368,
+		"bids": [["9638.0", "431"]],
+		"asks": [["9638.2", "12"], ["9638.3", "1"]]
+	}`)
+	book, err := service.OrderBook(context.Background(), &OrderBookRequest{Symbol: "BTCUSD_PERP", Limit: 5})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 16769853, book.LastUpdateId)
+	assert.EqualValues(t, 1591250106368, book.TransactionTime)
+	assert.EqualValues(t, "9638.0", book.Bids[0].Price.String())
+	assert.EqualValues(t, "431", book.Bids[0].Quantity.String())
+	assert.Len(t, book.Asks, 2)
+}
+
+func TestRecentTrades(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/trades", map[string]string{"symbol": "BTCUSD_PERP"}, `[
+		{"id": 28457, "price": "9635.0", "qty": "1", "baseQty": "0.01037883", "time": 1591250192508, "isBuyerMaker": true}
+	]`)
+	trades, err := service.RecentTrades(context.Background(), &TradesRequest{Symbol: "BTCUSD_PERP"})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 28457, trades[0].Id)
+	assert.EqualValues(t, "0.01037883", trades[0].BaseQty.String())
+	assert.True(t, trades[0].IsBuyerMaker)
+}
+
+func TestHistoricalTrades(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		mocks.CheckHeader(t, req.Header)
+		assert.EqualValues(t, "/dapi/v1/historicalTrades", req.URL.Path)
+		assert.EqualValues(t, "28457", req.URL.Query().Get("fromId"))
+		data := `[{"id": 28457, "price": "9635.0", "qty": "1", "baseQty": "0.01037883", "time": 1591250192508, "isBuyerMaker": true}]`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+	trades, err := service.HistoricalTrades(context.Background(), &TradesRequest{Symbol: "BTCUSD_PERP", FromId: 28457})
+	assert.Nil(t, err)
+	assert.Len(t, trades, 1)
+}
+
+func TestAggregateTrades(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/aggTrades", map[string]string{"symbol": "BTCUSD_PERP", "startTime": "1591250100000", "endTime": "1591250200000"}, `[
+		{"a": 416690, "p": "9642.4", "q": "3", "f": 595259, "l": 595259, "T": 1591250548649, "m": false}
+	]`)
+	trades, err := service.AggregateTrades(context.Background(), &AggregateTradesRequest{Symbol: "BTCUSD_PERP", StartTime: 1591250100000, EndTime: 1591250200000})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 416690, trades[0].AggregateTradeId)
+	assert.EqualValues(t, "9642.4", trades[0].Price.String())
+	assert.EqualValues(t, 1591250548649, trades[0].Timestamp)
+}
+
+func TestPremiumIndex(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/premiumIndex", map[string]string{"pair": "BTCUSD"}, `[{
+		"symbol": "BTCUSD_PERP",
+		"pair": "BTCUSD",
+		"markPrice": "11029.69574559",
+		"indexPrice": "10979.14437500",
+		"estimatedSettlePrice": "10981.74168236",
+		"lastFundingRate": "0.00071003",
+		"interestRate": "0.00010000",
+		"nextFundingTime": 1596096000000,
+		"time": 1596094042000
+	}]`)
+	prices, err := service.PremiumIndex(context.Background(), &SymbolRequest{Pair: "BTCUSD"})
+	assert.Nil(t, err)
+	assert.EqualValues(t, "11029.69574559", prices[0].MarkPrice.String())
+	assert.EqualValues(t, "0.00071003", prices[0].LastFundingRate.String())
+}
+
+func TestFundingRateHistory(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/fundingRate", map[string]string{"symbol": "BTCUSD_PERP", "limit": "2"}, `[
+		{"symbol": "BTCUSD_PERP", "fundingTime": 1596038400000, "fundingRate": "-0.00300000"},
+		{"symbol": "BTCUSD_PERP", "fundingTime": 1596067200000, "fundingRate": "-0.00300000"}
+	]`)
+	rates, err := service.FundingRateHistory(context.Background(), &FundingRateRequest{Symbol: "BTCUSD_PERP", Limit: 2})
+	assert.Nil(t, err)
+	assert.Len(t, rates, 2)
+	assert.EqualValues(t, -1, rates[0].FundingRate.Sign())
+}
+
+func TestKlines(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	data := `[[
+		1591258320000, "9640.7", "9642.4", "9640.6", "9642.0", "206", 1591258379999,
+		"2.13660389", 48, "119", "1.23424865", "0"
+	]]`
+
+	for path, call := range map[string]func(context.Context, *KlinesRequest) ([]*Kline, error){
+		"/dapi/v1/klines":             service.Klines,
+		"/dapi/v1/continuousKlines":   service.ContinuousKlines,
+		"/dapi/v1/indexPriceKlines":   service.IndexPriceKlines,
+		"/dapi/v1/markPriceKlines":    service.MarkPriceKlines,
+		"/dapi/v1/premiumIndexKlines": service.PremiumIndexKlines,
+	} {
+		mockResponse(t, path, map[string]string{"pair": "BTCUSD", "contractType": "PERPETUAL", "interval": "1m", "limit": "1"}, data)
+		klines, err := call(context.Background(), &KlinesRequest{Pair: "BTCUSD", ContractType: "PERPETUAL", Interval: "1m", Limit: 1})
+		assert.Nil(t, err, path)
+		assert.Len(t, klines, 1)
+		assert.EqualValues(t, 1591258320000, klines[0].OpenTime)
+		assert.EqualValues(t, "9642.0", klines[0].Close.String())
+		assert.EqualValues(t, 48, klines[0].NumberOfTrades)
+		assert.EqualValues(t, "1.23424865", klines[0].TakerBuyBaseAssetVolume.String())
+	}
+
+	mockResponse(t, "/dapi/v1/klines", map[string]string{"symbol": "BTCUSD_PERP", "interval": "1m"}, `[[1591258320000, "9640.7"]]`)
+	_, err := service.Klines(context.Background(), &KlinesRequest{Symbol: "BTCUSD_PERP", Interval: "1m"})
+	assert.NotNil(t, err)
+}
+
+func TestTickers(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/ticker/24hr", map[string]string{"symbol": "BTCUSD_200925"}, `[{
+		"symbol": "BTCUSD_200925",
+		"pair": "BTCUSD",
+		"priceChange": "136.6",
+		"priceChangePercent": "1.436",
+		"weightedAvgPrice": "9547.3",
+		"lastPrice": "9651.6",
+		"lastQty": "1",
+		"openPrice": "9515.0",
+		"highPrice": "9687.0",
+		"lowPrice": "9499.5",
+		"volume": "494109",
+		"baseVolume": "5192.94797687",
+		"openTime": 1591170300000,
+		"closeTime": 1591256718418,
+		"firstId": 600507,
+		"lastId": 697803,
+		"count": 97297
+	}]`)
+	tickers, err := service.Ticker24hr(context.Background(), &SymbolRequest{Symbol: "BTCUSD_200925"})
+	assert.Nil(t, err)
+	assert.EqualValues(t, "9651.6", tickers[0].LastPrice.String())
+	assert.EqualValues(t, 97297, tickers[0].Count)
+
+	mockResponse(t, "/dapi/v1/ticker/price", map[string]string{"pair": "BTCUSD"}, `[
+		{"symbol": "BTCUSD_200626", "ps": "BTCUSD", "price": "9647.8", "time": 1591257246176}
+	]`)
+	prices, err := service.PriceTicker(context.Background(), &SymbolRequest{Pair: "BTCUSD"})
+	assert.Nil(t, err)
+	assert.EqualValues(t, "BTCUSD", prices[0].Pair)
+	assert.EqualValues(t, "9647.8", prices[0].Price.String())
+
+	mockResponse(t, "/dapi/v1/ticker/bookTicker", nil, `[
+		{"symbol": "BTCUSD_200626", "pair": "BTCUSD", "bidPrice": "9650.1", "bidQty": "16", "askPrice": "9650.3", "askQty": "7", "time": 1591257300345}
+	]`)
+	books, err := service.BookTicker(context.Background(), &SymbolRequest{})
+	assert.Nil(t, err)
+	assert.EqualValues(t, "9650.3", books[0].AskPrice.String())
+}
+
+func TestOpenInterest(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	mockResponse(t, "/dapi/v1/openInterest", map[string]string{"symbol": "BTCUSD_200626"}, `{
+		"symbol": "BTCUSD_200626",
+		"pair": "BTCUSD",
+		"openInterest": "15004",
+		"contractType": "CURRENT_QUARTER",
+		"time": 1591261042378
+	}`)
+	openInterest, err := service.OpenInterest(context.Background(), "BTCUSD_200626")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "15004", openInterest.OpenInterest.String())
+	assert.EqualValues(t, "CURRENT_QUARTER", openInterest.ContractType)
+}
+
+func TestFuturesData(t *testing.T) {
+	service := getMockDeliveryMarketService()
+	request := &DataRequest{Pair: "BTCUSD", ContractType: "ALL", Period: "5m", Limit: 30}
+	query := map[string]string{"pair": "BTCUSD", "contractType": "ALL", "period": "5m", "limit": "30"}
+
+	mockResponse(t, "/futures/data/openInterestHist", query, `[
+		{"pair": "BTCUSD", "contractType": "CURRENT_QUARTER", "sumOpenInterest": "20403", "sumOpenInterestValue": "176196512.23400000", "timestamp": 1591261042378}
+	]`)
+	history, err := service.OpenInterestHistory(context.Background(), request)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "176196512.23400000", history[0].SumOpenInterestValue.String())
+
+	mockResponse(t, "/futures/data/topLongShortAccountRatio", query, `[
+		{"pair": "BTCUSD", "longShortRatio": "1.8105", "longAccount": "0.6442", "shortAccount": "0.3558", "timestamp": 1591261042378}
+	]`)
+	accounts, err := service.TopLongShortAccountRatio(context.Background(), request)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "0.6442", accounts[0].LongAccount.String())
+
+	mockResponse(t, "/futures/data/topLongShortPositionRatio", query, `[
+		{"pair": "BTCUSD", "longShortRatio": "0.7869", "longPosition": "0.4404", "shortPosition": "0.5596", "timestamp": 1592870400000}
+	]`)
+	positions, err := service.TopLongShortPositionRatio(context.Background(), request)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "0.5596", positions[0].ShortPosition.String())
+
+	mockResponse(t, "/futures/data/globalLongShortAccountRatio", query, `[
+		{"pair": "BTCUSD", "longShortRatio": "0.1960", "longAccount": "0.6622", "shortAccount": "0.3378", "timestamp": 1583139600000}
+	]`)
+	global, err := service.GlobalLongShortAccountRatio(context.Background(), request)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "0.1960", global[0].LongShortRatio.String())
+
+	mockResponse(t, "/futures/data/takerBuySellVol", query, `[
+		{"pair": "BTCUSD", "contractType": "CURRENT_QUARTER", "takerBuyVol": "387", "takerSellVol": "248", "takerBuyVolValue": "2342.1220", "takerSellVolValue": "4213.9800", "timestamp": 1591261042378}
+	]`)
+	volumes, err := service.TakerBuySellVolume(context.Background(), request)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "387", volumes[0].TakerBuyVol.String())
+}
+
+func TestNewDeliveryMarketServiceWithOptions(t *testing.T) {
+	_, err := NewDeliveryMarketServiceWithOptions()
+	assert.ErrorIs(t, err, delivery.ErrInvalidConfig)
+
+	service, err := NewDeliveryMarketServiceWithOptions(delivery.WithDomain(mocks.MockDomain, true),
+		delivery.WithHTTPClient(&mocks.MockHTTPClient{}))
+	assert.Nil(t, err)
+	mockResponse(t, "/dapi/v1/ping", nil, `{}`)
+	assert.Nil(t, service.Ping(context.Background()))
+}
@@ -0,0 +1,259 @@
+package delivery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/h9896/bingo/decimal"
+	"github.com/h9896/bingo/profile"
+	"github.com/h9896/bingo/rpc"
+)
+
+// Logger receives a line for every request sent by a service, *log.Logger satisfies it
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+var ErrInvalidConfig = errors.New("invalid delivery config")
+
+// Config of a delivery service, built by NewConfig
+type Config struct {
+	Domain        string
+	APIPrefix     string
+	UseSSL        bool
+	APIKey        string
+	Signer        rpc.Signer
+	HTTPClient    rpc.HTTPClient
+	RecvWindow    time.Duration
+	Timeout       time.Duration
+	Logger        Logger
+	ClientOptions []rpc.ClientOption
+
+	// Limit the recvWindow to the time left before the deadline of the context
+	RecvWindowFromDeadline bool
+
+	// Precision of the symbols, the prices and quantities of their orders are adjusted to it
+	Precisions map[string]Precision
+
+	// Validate the orders against the filters of exchangeInfo before they are sent
+	ValidateOrders bool
+	// Optional sources of the PERCENT_PRICE and MAX_NUM_ORDERS filters
+	MarkPrice      func(ctx context.Context, symbol string) (decimal.Decimal, error)
+	OpenOrderCount func(ctx context.Context, symbol string) (int64, error)
+
+	// Prefix of the generated client order ids
+	ClientOrderIdPrefix string
+}
+
+type Option func(cfg *Config)
+
+// Use the COIN-M futures endpoints of a profile
+func WithEnvironment(p profile.Profile) Option {
+	return func(cfg *Config) {
+		cfg.Domain = p.CoinM.RestDomain
+		cfg.APIPrefix = p.CoinM.APIPrefix
+		cfg.UseSSL = p.CoinM.UseSSL
+	}
+}
+
+// Use a custom domain, e.g. a proxy or a mock server
+func WithDomain(domain string, useSSL bool) Option {
+	return func(cfg *Config) {
+		cfg.Domain = domain
+		cfg.UseSSL = useSSL
+	}
+}
+
+func WithAPIKey(apiKey string) Option {
+	return func(cfg *Config) {
+		cfg.APIKey = apiKey
+	}
+}
+
+func WithSigner(signer rpc.Signer) Option {
+	return func(cfg *Config) {
+		cfg.Signer = signer
+	}
+}
+
+// Send the requests with the client instead of http.DefaultClient
+func WithHTTPClient(client rpc.HTTPClient) Option {
+	return func(cfg *Config) {
+		cfg.HTTPClient = client
+	}
+}
+
+// Send a recvWindow with the requests which do not set one, 0 keeps the default of the exchange
+func WithRecvWindow(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.RecvWindow = d
+	}
+}
+
+// Limit the recvWindow of every request to the time left before the deadline of its context,
+// so the exchange rejects an order which arrives after the caller has given up
+func WithRecvWindowFromDeadline() Option {
+	return func(cfg *Config) {
+		cfg.RecvWindowFromDeadline = true
+	}
+}
+
+// Adjust the prices and quantities of the orders of a symbol to its tick size and step size
+func WithPrecision(symbol string, p Precision) Option {
+	return func(cfg *Config) {
+		if cfg.Precisions == nil {
+			cfg.Precisions = map[string]Precision{}
+		}
+		cfg.Precisions[symbol] = p
+	}
+}
+
+// Load exchangeInfo on the first order and reject the orders which violate
+// the PRICE_FILTER, LOT_SIZE and MARKET_LOT_SIZE filters before they are sent.
+// The symbols without WithPrecision are adjusted to the tick size and step size of exchangeInfo.
+func WithOrderValidation() Option {
+	return func(cfg *Config) {
+		cfg.ValidateOrders = true
+	}
+}
+
+// Check the PERCENT_PRICE filter with the mark price of the source, it implies WithOrderValidation
+func WithMarkPrice(source func(ctx context.Context, symbol string) (decimal.Decimal, error)) Option {
+	return func(cfg *Config) {
+		cfg.ValidateOrders = true
+		cfg.MarkPrice = source
+	}
+}
+
+// Check the MAX_NUM_ORDERS filter with the open order count of the source, it implies WithOrderValidation
+func WithOpenOrderCount(source func(ctx context.Context, symbol string) (int64, error)) Option {
+	return func(cfg *Config) {
+		cfg.ValidateOrders = true
+		cfg.OpenOrderCount = source
+	}
+}
+
+// Start the generated client order ids with the prefix, e.g. the name of a strategy
+func WithClientOrderIdPrefix(prefix string) Option {
+	return func(cfg *Config) {
+		cfg.ClientOrderIdPrefix = prefix
+	}
+}
+
+// Bound every request including its retries by the timeout
+func WithTimeout(d time.Duration) Option {
+	return func(cfg *Config) {
+		cfg.Timeout = d
+	}
+}
+
+// Log the method, endpoint, duration and error of every request
+func WithLogger(logger Logger) Option {
+	return func(cfg *Config) {
+		cfg.Logger = logger
+	}
+}
+
+// Pass options to the underlying rpc client, e.g. rpc.WithRetryPolicy
+func WithClientOptions(opts ...rpc.ClientOption) Option {
+	return func(cfg *Config) {
+		cfg.ClientOptions = append(cfg.ClientOptions, opts...)
+	}
+}
+
+// Apply the options and validate the configuration
+func NewConfig(opts ...Option) (*Config, error) {
+	return newConfig(true, opts...)
+}
+
+// Apply the options and validate the configuration of a service of the public endpoints,
+// the api key and the signer are optional
+func NewPublicConfig(opts ...Option) (*Config, error) {
+	return newConfig(false, opts...)
+}
+
+func newConfig(signed bool, opts ...Option) (*Config, error) {
+	cfg := &Config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if err := cfg.validate(signed); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Check that the configuration is able to send signed requests
+func (cfg *Config) Validate() error {
+	return cfg.validate(true)
+}
+
+func (cfg *Config) validate(signed bool) error {
+	var problems []string
+	if cfg.Domain == "" {
+		problems = append(problems, "domain is empty, use WithEnvironment or WithDomain")
+	}
+	if signed && cfg.APIKey == "" {
+		problems = append(problems, "api key is empty, use WithAPIKey")
+	}
+	if signed && cfg.Signer == nil {
+		problems = append(problems, "signer is missing, use WithSigner")
+	}
+	if cfg.RecvWindow < 0 || cfg.RecvWindow > rpc.MaxRecvWindow {
+		problems = append(problems, fmt.Sprintf("recvWindow %v is out of range [0, %v], 0 means the default of the exchange", cfg.RecvWindow, rpc.MaxRecvWindow))
+	}
+	if cfg.Timeout < 0 {
+		problems = append(problems, fmt.Sprintf("timeout %v is negative", cfg.Timeout))
+	}
+	if err := validClientOrderIdPrefix(cfg.ClientOrderIdPrefix); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for symbol, p := range cfg.Precisions {
+		if p.TickSize.Sign() < 0 || p.StepSize.Sign() < 0 {
+			problems = append(problems, fmt.Sprintf("precision of %s is negative", symbol))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Create the rpc client of the configuration
+func (cfg *Config) NewHttpClient() rpc.GenericHttpClient {
+	opts := []rpc.ClientOption{}
+	if cfg.RecvWindow > 0 {
+		opts = append(opts, rpc.WithRecvWindow(cfg.RecvWindow))
+	}
+	if cfg.RecvWindowFromDeadline {
+		opts = append(opts, rpc.WithRecvWindowFromDeadline())
+	}
+	if cfg.Timeout > 0 {
+		opts = append(opts, rpc.WithTimeout(cfg.Timeout))
+	}
+	if cfg.Logger != nil {
+		opts = append(opts, rpc.WithInterceptors(logging(cfg.Logger)))
+	}
+	opts = append(opts, cfg.ClientOptions...)
+
+	return rpc.NewGenericHttpClient(cfg.APIKey, cfg.UseSSL, cfg.HTTPClient, opts...)
+}
+
+func logging(logger Logger) rpc.Interceptor {
+	return func(ctx context.Context, req *rpc.Request, next rpc.Handler) (*http.Response, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+		if err != nil {
+			logger.Printf("%s %s %v error: %v", req.Method(), req.Endpoint(), time.Since(start), err)
+		} else {
+			logger.Printf("%s %s %v status: %d", req.Method(), req.Endpoint(), time.Since(start), resp.StatusCode)
+		}
+		return resp, err
+	}
+}
@@ -0,0 +1,109 @@
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/h9896/bingo/mocks"
+	"github.com/h9896/bingo/profile"
+	"github.com/h9896/bingo/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConfig(t *testing.T) {
+	cfg, err := NewConfig(WithEnvironment(profile.Testnet), WithAPIKey(mocks.MockApiKey),
+		WithSigner(rpc.NewHMACSigner(mocks.MockSecret)), WithRecvWindow(5*time.Second), WithTimeout(time.Second))
+	assert.Nil(t, err)
+	assert.EqualValues(t, profile.Testnet.CoinM.RestDomain, cfg.Domain)
+	assert.EqualValues(t, "dapi/v1", cfg.APIPrefix)
+	assert.True(t, cfg.UseSSL)
+	assert.EqualValues(t, 5*time.Second, cfg.RecvWindow)
+	assert.EqualValues(t, time.Second, cfg.Timeout)
+}
+
+func TestEndpoint(t *testing.T) {
+	assert.EqualValues(t, "dapi.binance.com/dapi/v1/order", Endpoint("dapi.binance.com", "", EntryPointOrder))
+	assert.EqualValues(t, "dapi.binance.com/dapi/v1/order", Endpoint("dapi.binance.com", DefaultAPIPrefix, EntryPointOrder))
+	assert.EqualValues(t, "proxy.local/binance/dapi/v1/order", Endpoint("proxy.local", "binance/dapi/v1", EntryPointOrder))
+	// Only the DefaultAPIPrefix is replaced
+	assert.EqualValues(t, "proxy.local/dapi/v2/leverageBracket", Endpoint("proxy.local", "binance/dapi/v1", EntryPointLeverageBracketV2))
+	assert.EqualValues(t, "proxy.local/futures/data/takerBuySellVol", Endpoint("proxy.local", "binance/dapi/v1", EntryPointTakerBuySellVol))
+}
+
+func TestNewConfigInvalid(t *testing.T) {
+	_, err := NewConfig()
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "domain is empty")
+	assert.Contains(t, err.Error(), "api key is empty")
+	assert.Contains(t, err.Error(), "signer is missing")
+
+	_, err = NewConfig(WithDomain(mocks.MockDomain, true), WithAPIKey(mocks.MockApiKey),
+		WithSigner(rpc.NewHMACSigner(mocks.MockSecret)), WithRecvWindow(time.Minute+time.Millisecond))
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "recvWindow")
+	assert.Contains(t, err.Error(), "[0, 1m0s]")
+
+	_, err = NewConfig(WithDomain(mocks.MockDomain, true), WithAPIKey(mocks.MockApiKey),
+		WithSigner(rpc.NewHMACSigner(mocks.MockSecret)), WithTimeout(-time.Second))
+	assert.True(t, errors.Is(err, ErrInvalidConfig))
+	assert.Contains(t, err.Error(), "timeout")
+}
+
+type bufferLogger struct {
+	bytes.Buffer
+}
+
+func (l *bufferLogger) Printf(format string, v ...interface{}) {
+	fmt.Fprintf(&l.Buffer, format+"\n", v...)
+}
+
+func TestConfigNewHttpClient(t *testing.T) {
+	logger := &bufferLogger{}
+	cfg, err := NewConfig(WithDomain(mocks.MockDomain, true), WithAPIKey(mocks.MockApiKey),
+		WithSigner(rpc.NewHMACSigner(mocks.MockSecret)), WithHTTPClient(&mocks.MockHTTPClient{}),
+		WithRecvWindow(2*time.Second), WithLogger(logger))
+	assert.Nil(t, err)
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.EqualValues(t, mocks.MockApiKey, req.Header.Get("X-MBX-APIKEY"))
+		assert.EqualValues(t, "2000", req.URL.Query().Get("recvWindow"))
+		resp = &http.Response{StatusCode: http.StatusOK, Request: req}
+		return
+	}
+
+	client := cfg.NewHttpClient()
+	req := client.GetHttpRequest(rpc.SetEndpoint(fmt.Sprintf("%s/%s", cfg.Domain, EntryPointOpenOrders)), rpc.SetMethod("get"),
+		rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(cfg.Signer))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Contains(t, logger.String(), "GET "+mocks.MockDomain+"/"+EntryPointOpenOrders)
+	assert.Contains(t, logger.String(), "status: 200")
+}
+
+func TestConfigRecvWindowFromDeadline(t *testing.T) {
+	cfg, err := NewConfig(WithDomain(mocks.MockDomain, true), WithAPIKey(mocks.MockApiKey),
+		WithSigner(rpc.NewHMACSigner(mocks.MockSecret)), WithHTTPClient(&mocks.MockHTTPClient{}),
+		WithRecvWindow(5*time.Second), WithRecvWindowFromDeadline())
+	assert.Nil(t, err)
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		recvWindow, _ := strconv.ParseInt(req.URL.Query().Get("recvWindow"), 10, 64)
+		assert.True(t, recvWindow > 0 && recvWindow <= 500, "recvWindow %d", recvWindow)
+		resp = &http.Response{StatusCode: http.StatusOK, Request: req}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	client := cfg.NewHttpClient()
+	req := client.GetHttpRequest(rpc.SetEndpoint(fmt.Sprintf("%s/%s", cfg.Domain, EntryPointOrder)), rpc.SetMethod("post"),
+		rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(cfg.Signer))
+	_, err = client.ExecuteHttpOperation(ctx, req)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,20 @@
+package delivery
+
+import "github.com/h9896/bingo/decimal"
+
+// Precision of the prices and quantities of a symbol, from the PRICE_FILTER and LOT_SIZE filters of exchangeInfo
+type Precision struct {
+	TickSize decimal.Decimal
+	StepSize decimal.Decimal
+}
+
+// Round a price to the nearest tick, it is kept as it is without a tick size
+func (p Precision) Price(price decimal.Decimal) decimal.Decimal {
+	return price.RoundToStep(p.TickSize)
+}
+
+// Truncate a quantity to the step, so an order is never larger than requested.
+// It is kept as it is without a step size.
+func (p Precision) Quantity(quantity decimal.Decimal) decimal.Decimal {
+	return quantity.TruncateToStep(p.StepSize)
+}
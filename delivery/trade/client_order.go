@@ -0,0 +1,126 @@
+package trade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	pb "github.com/h9896/bingo-pkg-protobuf/services/delivery/v1"
+	"github.com/h9896/bingo/delivery"
+	"github.com/h9896/bingo/rpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// Timeout waiting for response from backend server, the execution status is unknown
+	codeUnknownStatus = -1007
+	// Order does not exist
+	codeOrderNotExist = -2013
+
+	// Time given to query an order whose status is unknown
+	reconcileTimeout = 10 * time.Second
+	// Queries of an order which does not exist yet, with a growing delay between them
+	reconcileAttempts = 3
+	reconcileDelay    = 200 * time.Millisecond
+)
+
+// OrderStatusError is a new order whose status is unknown after a timeout, it may or may not have been placed.
+// Query it by its client order id later to find out, a QueryErr with the code -2013 means it was not found yet.
+// Send it again only once it is known not to be placed.
+type OrderStatusError struct {
+	Symbol        string
+	ClientOrderId string
+	// The error of the new order
+	Err error
+	// The error of the query of the order
+	QueryErr error
+}
+
+func (e *OrderStatusError) Error() string {
+	return fmt.Sprintf("<OrderStatusError> symbol=%s, clientOrderId=%s, err=%v, query=%v", e.Symbol, e.ClientOrderId, e.Err, e.QueryErr)
+}
+
+func (e *OrderStatusError) Unwrap() error {
+	return e.Err
+}
+
+// Query an order by its client order id
+func (s *deliveryTradeService) QueryOrderByClientId(ctx context.Context, symbol, clientOrderId string) (*pb.QueryOrderResponse, error) {
+	endpoint := s.endpoint(delivery.EntryPointOrder)
+	body := []*rpc.HttpParameter{
+		{Key: "symbol", Val: symbol},
+		{Key: "origClientOrderId", Val: clientOrderId},
+	}
+
+	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointOrder))
+
+	return rpc.Do[*pb.QueryOrderResponse](ctx, s.httpclient, req)
+}
+
+// Cancel an order by its client order id
+func (s *deliveryTradeService) CancelOrderByClientId(ctx context.Context, symbol, clientOrderId string) (*pb.CancelOrderResponse, error) {
+	return s.CancelOrder(ctx, &pb.CancelOrderRequest{Symbol: symbol, OrigClientOrderId: clientOrderId})
+}
+
+// Check whether a new order may have been executed although it failed.
+// Only a network error or a done context after the request is handed to the http client, a -1007 or a 5xx
+// leaves the status unknown, a request which failed locally, was not dialed or was rejected by the exchange is not executed.
+func isUnknownStatus(err error) bool {
+	var apiErr *rpc.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == codeUnknownStatus || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return false
+	}
+	// The http client wraps its errors in a url.Error, a context done before the request is sent is not wrapped
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		return true
+	}
+	return rpc.IsTransportError(err)
+}
+
+// Find out whether a new order whose status is unknown has been placed by querying its client order id.
+// The context of the new order may be done, so the query has its own timeout.
+// An order which does not exist yet is queried again, as the exchange may not have processed it,
+// and an OrderStatusError is returned when it is still not found.
+func (s *deliveryTradeService) reconcile(symbol, clientOrderId string, cause error) (*pb.NewOrderResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reconcileTimeout)
+	defer cancel()
+
+	var order *pb.QueryOrderResponse
+	var err error
+	for attempt := 1; ; attempt++ {
+		order, err = s.QueryOrderByClientId(ctx, symbol, clientOrderId)
+		var apiErr *rpc.APIError
+		if !errors.As(err, &apiErr) || apiErr.Code != codeOrderNotExist || attempt == reconcileAttempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, &OrderStatusError{Symbol: symbol, ClientOrderId: clientOrderId, Err: cause, QueryErr: err}
+		case <-time.After(reconcileDelay * time.Duration(attempt)):
+		}
+	}
+	if err != nil {
+		return nil, &OrderStatusError{Symbol: symbol, ClientOrderId: clientOrderId, Err: cause, QueryErr: err}
+	}
+
+	// Both messages describe an order with the same fields
+	data, err := protojson.Marshal(order)
+	if err != nil {
+		return nil, &OrderStatusError{Symbol: symbol, ClientOrderId: clientOrderId, Err: cause, QueryErr: err}
+	}
+	resp := &pb.NewOrderResponse{}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, resp); err != nil {
+		return nil, &OrderStatusError{Symbol: symbol, ClientOrderId: clientOrderId, Err: cause, QueryErr: err}
+	}
+	return resp, nil
+}
@@ -2,48 +2,151 @@ package trade
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"net/http"
+	"strconv"
 
-	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	pb "github.com/h9896/bingo-pkg-protobuf/services/delivery/v1"
+	"github.com/h9896/bingo/decimal"
 	"github.com/h9896/bingo/delivery"
+	"github.com/h9896/bingo/profile"
 	"github.com/h9896/bingo/rpc"
-	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
+// DeliveryTradeService extends the generated service with the methods which accept exact decimal amounts
+type DeliveryTradeService interface {
+	pb.DeliveryTradeServiceServer
+
+	// Send in a new order, the non-zero amounts take precedence over the float64 fields of the request
+	NewOrderWithAmounts(ctx context.Context, request *pb.NewOrderRequest, amounts Amounts) (*pb.NewOrderResponse, error)
+
+	// Modify an order, the non-zero amounts take precedence over the float64 fields of the request
+	ModifyOrderWithAmounts(ctx context.Context, request *pb.ModifyOrderRequest, amounts Amounts) (*pb.ModifyOrderResponse, error)
+
+	// Place multiple orders, every order has its own result
+	PlaceMultipleOrders(ctx context.Context, request *pb.PlaceMultipleOrdersRequest) ([]rpc.BatchResult[*pb.NewOrderResponse], error)
+
+	// Modify multiple orders, every order has its own result
+	ModifyMultipleOrders(ctx context.Context, request *pb.ModifyMultipleOrdersRequest) ([]rpc.BatchResult[*pb.ModifyOrderResponse], error)
+
+	// Cancel multiple orders of a symbol, every order has its own result
+	CancelMultipleOrders(ctx context.Context, request *CancelMultipleOrdersRequest) ([]rpc.BatchResult[*pb.CancelOrderResponse], error)
+
+	// Query an order by the client order id, e.g. the one of a NewOrderResponse or an OrderStatusError
+	QueryOrderByClientId(ctx context.Context, symbol, clientOrderId string) (*pb.QueryOrderResponse, error)
+
+	// Cancel an order by the client order id
+	CancelOrderByClientId(ctx context.Context, symbol, clientOrderId string) (*pb.CancelOrderResponse, error)
+
+	// Load exchangeInfo of the order validation again, e.g. after a symbol is listed or its filters are changed.
+	// It does nothing when the validation is disabled.
+	ReloadExchangeInfo(ctx context.Context) error
+}
+
+const (
+	// The maximum number of orders of a batch request
+	MaxBatchOrders = 5
+	// The maximum number of orders cancelled by a request
+	MaxCancelOrders = 10
+)
+
+// Orders of a symbol to cancel, identified by either the order ids or the client order ids
+type CancelMultipleOrdersRequest struct {
+	Symbol                string
+	OrderIdList           []int64
+	OrigClientOrderIdList []string
+	RecvWindow            int64
+}
+
+// Exact decimal amounts of an order
+type Amounts struct {
+	Quantity        decimal.Decimal
+	Price           decimal.Decimal
+	StopPrice       decimal.Decimal
+	ActivationPrice decimal.Decimal
+}
+
 type deliveryTradeService struct {
 	httpclient rpc.GenericHttpClient
 	domain     string
-	secret     string
-	m          *runtime.JSONPb
+	apiPrefix  string
+	signer     rpc.Signer
+	precisions map[string]delivery.Precision
+
+	// Order validation against exchangeInfo, nil if it is disabled
+	rules          *delivery.Rules
+	markPrice      func(ctx context.Context, symbol string) (decimal.Decimal, error)
+	openOrderCount func(ctx context.Context, symbol string) (int64, error)
+
+	// Generator of the client order ids of the orders which do not set one
+	clientOrderIds *delivery.ClientOrderIds
 }
 
-func NewDeliveryTradeService(domain, apikey, secret string, useSSL bool, client rpc.HTTPClient) pb.DeliveryTradeServiceServer {
+func NewDeliveryTradeService(domain, apikey string, signer rpc.Signer, useSSL bool, client rpc.HTTPClient, opts ...rpc.ClientOption) DeliveryTradeService {
+	// An empty prefix is always valid
+	clientOrderIds, _ := delivery.NewClientOrderIds("")
 	service := &deliveryTradeService{
-		domain: domain,
-		secret: secret,
-		m: &runtime.JSONPb{
-			UnmarshalOptions: protojson.UnmarshalOptions{
-				DiscardUnknown: true,
-			},
-			MarshalOptions: protojson.MarshalOptions{
-				UseProtoNames: true,
-			},
-		},
+		domain:         domain,
+		signer:         signer,
+		clientOrderIds: clientOrderIds,
 	}
 	if client == nil {
-		service.httpclient = rpc.NewGenericHttpClient(apikey, useSSL, nil)
+		service.httpclient = rpc.NewGenericHttpClient(apikey, useSSL, nil, opts...)
 	} else {
-		service.httpclient = rpc.NewGenericHttpClient(apikey, useSSL, client)
+		service.httpclient = rpc.NewGenericHttpClient(apikey, useSSL, client, opts...)
 	}
 
 	return service
 }
 
+// Create the service with the COIN-M futures endpoints of a profile
+func NewDeliveryTradeServiceWithProfile(p profile.Profile, apikey string, signer rpc.Signer, client rpc.HTTPClient, opts ...rpc.ClientOption) DeliveryTradeService {
+	service := NewDeliveryTradeService(p.CoinM.RestDomain, apikey, signer, p.CoinM.UseSSL, client, opts...).(*deliveryTradeService)
+	service.apiPrefix = p.CoinM.APIPrefix
+	return service
+}
+
+// Create the service with the options, an invalid configuration is returned as an error
+func NewDeliveryTradeServiceWithOptions(opts ...delivery.Option) (DeliveryTradeService, error) {
+	cfg, err := delivery.NewConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOrderIds, err := delivery.NewClientOrderIds(cfg.ClientOrderIdPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	service := &deliveryTradeService{
+		httpclient:     cfg.NewHttpClient(),
+		domain:         cfg.Domain,
+		apiPrefix:      cfg.APIPrefix,
+		signer:         cfg.Signer,
+		precisions:     cfg.Precisions,
+		clientOrderIds: clientOrderIds,
+	}
+	if cfg.ValidateOrders {
+		service.rules = delivery.NewRules(func(ctx context.Context) (*delivery.ExchangeInfo, error) {
+			return delivery.GetExchangeInfo(ctx, service.httpclient, service.domain, service.apiPrefix)
+		})
+		service.markPrice = cfg.MarkPrice
+		service.openOrderCount = cfg.OpenOrderCount
+	}
+
+	return service, nil
+}
+
+// Get the endpoint of an entry point with the API prefix of the service
+func (s *deliveryTradeService) endpoint(entryPoint string) string {
+	return delivery.Endpoint(s.domain, s.apiPrefix, entryPoint)
+}
+
 // Change user's position mode (Hedge Mode or One-way Mode ) on EVERY symbol
 func (s *deliveryTradeService) ChangePositionMode(ctx context.Context, request *pb.ChangePositionModeRequest) (*pb.ChangePositionModeResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointPositionMode)
+	endpoint := s.endpoint(delivery.EntryPointPositionMode)
 	body := []*rpc.HttpParameter{
 		{Key: "dualSidePosition", Val: request.GetDualSidePosition()},
 	}
@@ -51,36 +154,56 @@ func (s *deliveryTradeService) ChangePositionMode(ctx context.Context, request *
 		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", request.GetRecvWindow())})
 	}
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("post"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointPositionMode))
 
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+	return rpc.Do[*pb.ChangePositionModeResponse](ctx, s.httpclient, req)
+}
 
+// Send in a new order.
+func (s *deliveryTradeService) NewOrder(ctx context.Context, request *pb.NewOrderRequest) (*pb.NewOrderResponse, error) {
+	return s.NewOrderWithAmounts(ctx, request, Amounts{})
+}
+
+// Send in a new order with exact decimal amounts.
+func (s *deliveryTradeService) NewOrderWithAmounts(ctx context.Context, request *pb.NewOrderRequest, amounts Amounts) (*pb.NewOrderResponse, error) {
+	precision, err := s.precision(ctx, request.GetSymbol())
 	if err != nil {
 		return nil, err
 	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
+	quantity, err := amount("quantity", amounts.Quantity, request.GetQuantity())
+	if err != nil {
+		return nil, err
 	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
+	price, err := amount("price", amounts.Price, request.GetPrice())
 	if err != nil {
 		return nil, err
 	}
-
-	out := &pb.ChangePositionModeResponse{}
-
-	err = s.m.Unmarshal(respBody, out)
-
+	stopPrice, err := amount("stopPrice", amounts.StopPrice, request.GetStopPrice())
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
-}
+	activationPrice, err := amount("activationPrice", amounts.ActivationPrice, request.GetActivationPrice())
+	if err != nil {
+		return nil, err
+	}
+	quantity, err = stepQuantity(precision, quantity)
+	if err != nil {
+		return nil, err
+	}
+	price, stopPrice, activationPrice = precision.Price(price), precision.Price(stopPrice), precision.Price(activationPrice)
 
-// Send in a new order.
-func (s *deliveryTradeService) NewOrder(ctx context.Context, request *pb.NewOrderRequest) (*pb.NewOrderResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointOrder)
+	order := delivery.OrderCheck{
+		Symbol:    request.GetSymbol(),
+		Type:      request.GetType().String(),
+		Quantity:  quantity,
+		Price:     price,
+		StopPrice: stopPrice,
+	}
+	if err := s.validate(ctx, order, true); err != nil {
+		return nil, err
+	}
+
+	endpoint := s.endpoint(delivery.EntryPointOrder)
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
 		{Key: "side", Val: request.GetSide().String()},
@@ -91,36 +214,39 @@ func (s *deliveryTradeService) NewOrder(ctx context.Context, request *pb.NewOrde
 		body = append(body, &rpc.HttpParameter{Key: "positionSide", Val: request.GetPositionSide().String()})
 	}
 
-	if request.GetQuantity() != 0 {
-		body = append(body, &rpc.HttpParameter{Key: "quantity", Val: fmt.Sprintf("%v", request.GetQuantity())})
+	if !quantity.IsZero() {
+		body = append(body, &rpc.HttpParameter{Key: "quantity", Val: quantity.String()})
 	}
 
 	if request.GetReduceOnly() != "" {
 		body = append(body, &rpc.HttpParameter{Key: "reduceOnly", Val: request.GetReduceOnly()})
 	}
 
-	if request.GetPrice() != 0 {
-		body = append(body, &rpc.HttpParameter{Key: "price", Val: fmt.Sprintf("%v", request.GetPrice())})
+	if !price.IsZero() {
+		body = append(body, &rpc.HttpParameter{Key: "price", Val: price.String()})
 	}
 
-	if request.GetNewClientOrderId() != "" {
-		body = append(body, &rpc.HttpParameter{Key: "newClientOrderId", Val: request.GetNewClientOrderId()})
+	// The client order id is always sent, so an order whose status is unknown can be queried by it
+	clientOrderId := request.GetNewClientOrderId()
+	if clientOrderId == "" {
+		clientOrderId = s.clientOrderIds.Next()
 	}
+	body = append(body, &rpc.HttpParameter{Key: "newClientOrderId", Val: clientOrderId})
 
-	if request.GetStopPrice() != 0 {
-		body = append(body, &rpc.HttpParameter{Key: "stopPrice", Val: fmt.Sprintf("%v", request.GetStopPrice())})
+	if !stopPrice.IsZero() {
+		body = append(body, &rpc.HttpParameter{Key: "stopPrice", Val: stopPrice.String()})
 	}
 
 	if request.GetClosePosition() != "" {
 		body = append(body, &rpc.HttpParameter{Key: "closePosition", Val: request.GetClosePosition()})
 	}
 
-	if request.GetActivationPrice() != 0 {
-		body = append(body, &rpc.HttpParameter{Key: "activationPrice", Val: fmt.Sprintf("%v", request.GetActivationPrice())})
+	if !activationPrice.IsZero() {
+		body = append(body, &rpc.HttpParameter{Key: "activationPrice", Val: activationPrice.String()})
 	}
 
 	if request.GetCallbackRate() != 0 {
-		body = append(body, &rpc.HttpParameter{Key: "callbackRate", Val: fmt.Sprintf("%v", request.GetCallbackRate())})
+		body = append(body, &rpc.HttpParameter{Key: "callbackRate", Val: strconv.FormatFloat(request.GetCallbackRate(), 'f', -1, 64)})
 	}
 
 	if request.GetWorkingType() != 0 {
@@ -144,36 +270,19 @@ func (s *deliveryTradeService) NewOrder(ctx context.Context, request *pb.NewOrde
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("post"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), rpc.SetOrderCount(1),
+		delivery.Weight(delivery.EntryPointOrder))
 
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	resp, err := rpc.Do[*pb.NewOrderResponse](ctx, s.httpclient, req)
+	if err != nil && isUnknownStatus(err) {
+		return s.reconcile(request.GetSymbol(), clientOrderId, err)
 	}
-
-	out := &pb.NewOrderResponse{}
-
-	err = s.m.Unmarshal(respBody, out)
-
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+	return resp, err
 }
 
 // Cancel an active order.
 func (s *deliveryTradeService) CancelOrder(ctx context.Context, request *pb.CancelOrderRequest) (*pb.CancelOrderResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointOrder)
+	endpoint := s.endpoint(delivery.EntryPointOrder)
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
 	}
@@ -191,37 +300,49 @@ func (s *deliveryTradeService) CancelOrder(ctx context.Context, request *pb.Canc
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("delete"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointOrder))
 
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+	return rpc.Do[*pb.CancelOrderResponse](ctx, s.httpclient, req)
+}
 
+// Order modify function, currently only LIMIT order modification is supported,
+// modified orders will be reordered in the match queue
+func (s *deliveryTradeService) ModifyOrder(ctx context.Context, request *pb.ModifyOrderRequest) (*pb.ModifyOrderResponse, error) {
+	return s.ModifyOrderWithAmounts(ctx, request, Amounts{})
+}
+
+// Modify an order with exact decimal amounts.
+func (s *deliveryTradeService) ModifyOrderWithAmounts(ctx context.Context, request *pb.ModifyOrderRequest, amounts Amounts) (*pb.ModifyOrderResponse, error) {
+	precision, err := s.precision(ctx, request.GetSymbol())
 	if err != nil {
 		return nil, err
 	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
+	quantity, err := amount("quantity", amounts.Quantity, request.GetQuantity())
+	if err != nil {
+		return nil, err
 	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
+	price, err := amount("price", amounts.Price, request.GetPrice())
 	if err != nil {
 		return nil, err
 	}
-
-	out := &pb.CancelOrderResponse{}
-
-	err = s.m.Unmarshal(respBody, out)
-
+	quantity, err = stepQuantity(precision, quantity)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
-}
+	price = precision.Price(price)
 
-// Order modify function, currently only LIMIT order modification is supported,
-// modified orders will be reordered in the match queue
-func (s *deliveryTradeService) ModifyOrder(ctx context.Context, request *pb.ModifyOrderRequest) (*pb.ModifyOrderResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointOrder)
+	// Only LIMIT orders can be modified
+	order := delivery.OrderCheck{
+		Symbol:   request.GetSymbol(),
+		Type:     pb.OrderType_LIMIT.String(),
+		Quantity: quantity,
+		Price:    price,
+	}
+	if err := s.validate(ctx, order, false); err != nil {
+		return nil, err
+	}
+
+	endpoint := s.endpoint(delivery.EntryPointOrder)
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
 		{Key: "side", Val: request.GetSide().String()},
@@ -239,257 +360,352 @@ func (s *deliveryTradeService) ModifyOrder(ctx context.Context, request *pb.Modi
 		body = append(body, &rpc.HttpParameter{Key: "origClientOrderId", Val: request.GetOrigClientOrderId()})
 	}
 
-	if request.GetQuantity() != 0 {
-		body = append(body, &rpc.HttpParameter{Key: "quantity", Val: fmt.Sprintf("%v", request.GetQuantity())})
+	if !quantity.IsZero() {
+		body = append(body, &rpc.HttpParameter{Key: "quantity", Val: quantity.String()})
 	}
 
-	if request.GetPrice() != 0 {
-		body = append(body, &rpc.HttpParameter{Key: "price", Val: fmt.Sprintf("%v", request.GetPrice())})
+	if !price.IsZero() {
+		body = append(body, &rpc.HttpParameter{Key: "price", Val: price.String()})
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("put"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointOrder))
 
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+	return rpc.Do[*pb.ModifyOrderResponse](ctx, s.httpclient, req)
+}
 
-	if err != nil {
-		return nil, err
+// Place multiple orders, up to MaxBatchOrders.
+// An order rejected locally or by the exchange is reported in its slot without failing the others.
+func (s *deliveryTradeService) PlaceMultipleOrders(ctx context.Context, request *pb.PlaceMultipleOrdersRequest) ([]rpc.BatchResult[*pb.NewOrderResponse], error) {
+	orders := request.GetBatchOrders()
+	if len(orders) == 0 || len(orders) > MaxBatchOrders {
+		return nil, fmt.Errorf("the number of batch orders %d is out of range [1, %d]", len(orders), MaxBatchOrders)
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
+	batch := newBatch[*pb.NewOrderResponse](len(orders))
+	// The earlier orders of the batch count as open orders of their symbol
+	pending := map[string]int64{}
+	for i, order := range orders {
+		params, err := s.batchOrder(ctx, order, pending[order.GetSymbol()])
+		if err == nil {
+			pending[order.GetSymbol()]++
+		}
+		batch.add(i, params, err)
 	}
 
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	return sendBatch(ctx, s, batch, http.MethodPost, request.GetRecvWindow())
+}
+
+// Modify multiple LIMIT orders, up to MaxBatchOrders.
+// An order rejected locally or by the exchange is reported in its slot without failing the others.
+func (s *deliveryTradeService) ModifyMultipleOrders(ctx context.Context, request *pb.ModifyMultipleOrdersRequest) ([]rpc.BatchResult[*pb.ModifyOrderResponse], error) {
+	orders := request.GetBatchOrders()
+	if len(orders) == 0 || len(orders) > MaxBatchOrders {
+		return nil, fmt.Errorf("the number of batch orders %d is out of range [1, %d]", len(orders), MaxBatchOrders)
 	}
 
-	out := &pb.ModifyOrderResponse{}
+	batch := newBatch[*pb.ModifyOrderResponse](len(orders))
+	for i, order := range orders {
+		params, err := s.modifyBatchOrder(ctx, order)
+		batch.add(i, params, err)
+	}
 
-	err = s.m.Unmarshal(respBody, out)
+	return sendBatch(ctx, s, batch, http.MethodPut, request.GetRecvWindow())
+}
 
-	if err != nil {
-		return nil, err
+// Cancel multiple orders of a symbol, up to MaxCancelOrders.
+// An order which fails to be cancelled is reported in its slot without failing the others.
+func (s *deliveryTradeService) CancelMultipleOrders(ctx context.Context, request *CancelMultipleOrdersRequest) ([]rpc.BatchResult[*pb.CancelOrderResponse], error) {
+	ids, clientIds := len(request.OrderIdList), len(request.OrigClientOrderIdList)
+	if (ids == 0) == (clientIds == 0) {
+		return nil, fmt.Errorf("either orderIdList or origClientOrderIdList must be set")
+	}
+	if ids > MaxCancelOrders || clientIds > MaxCancelOrders {
+		return nil, fmt.Errorf("the number of orders to cancel exceeds %d", MaxCancelOrders)
 	}
-	return out, nil
-}
-
-// // Place Multiple Orders
-// func (s *deliveryTradeService) PlaceMultipleOrders(ctx context.Context, request *pb.PlaceMultipleOrdersRequest) (*pb.PlaceMultipleOrdersResponse, error) {
-// 	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointMultipleOrders)
-// 	batch := ""
-// 	for _, val := range request.GetBatchOrders() {
-// 		subBody, err := s.m.Marshal(val)
-// 		if err != nil {
-// 			return nil, err
-// 		}
-// 		if batch != "" {
-// 			batch += fmt.Sprintf(",%s", string(subBody[:]))
-// 		} else {
-// 			batch += string(subBody[:])
-// 		}
-// 	}
-// 	body := []*rpc.HttpParameter{
-// 		{Key: "batchOrders", Val: fmt.Sprintf("[%s]", batch)},
-// 	}
-//
-// 	if request.GetRecvWindow() != 0 {
-// 		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", request.GetRecvWindow())})
-// 	}
-//
-// 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("POST"),
-// 		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-//
-// 	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-//
-// 	if err != nil {
-// 		return nil, err
-// 	}
-//
-// 	if resp.StatusCode != 200 {
-// 		return nil, fmt.Errorf("Something wrong")
-// 	}
-//
-// 	respBody, err := ioutil.ReadAll(resp.Body)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	fmt.Println(string(respBody[:]))
-// 	fmt.Println(req)
-// 	out := &pb.PlaceMultipleOrdersResponse{}
-//
-// 	batchOrders := &[]*pb.NewOrderResponse{}
-//
-// 	err = s.m.Unmarshal(respBody, batchOrders)
-//
-// 	if err != nil {
-// 		return nil, err
-// 	}
-//
-// 	out.BatchOrders = *batchOrders
-//
-// 	return out, nil
-// }
-
-// // Modify Multiple Orders
-// func (s *deliveryTradeService) ModifyMultipleOrders(ctx context.Context, request *pb.ModifyMultipleOrdersRequest) (*pb.ModifyMultipleOrdersResponse, error) {
-// 	endpoint := fmt.Sprintf("%s/%s", s.domain, EntryMultipleOrders)
-// 	body := []*rpc.HttpParameter{
-// 		{Key: "batchOrders", Val: fmt.Sprintf("%v", request.GetBatchOrders())},
-// 	}
-//
-// 	if request.GetRecvWindow() != 0 {
-// 		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", request.GetRecvWindow())})
-// 	}
-//
-// 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("PUT"),
-// 		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-//
-// 	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-//
-// 	if err != nil {
-// 		return nil, err
-// 	}
-//
-// 	if resp.StatusCode != 200 {
-// 		return nil, fmt.Errorf("Something wrong")
-// 	}
-//
-// 	respBody, err := ioutil.ReadAll(resp.Body)
-// 	if err != nil {
-// 		return nil, err
-// 	}
-//
-// 	out := &pb.ModifyMultipleOrdersResponse{}
-//
-// 	err = s.m.Unmarshal(respBody, out.BatchOrders)
-//
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	return out, nil
-// }
 
-// Cancel All Open Orders
-func (s *deliveryTradeService) CancelAllOpenOrders(ctx context.Context, request *pb.CancelAllOpenOrdersRequest) (*pb.CancelAllOpenOrdersResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointAllOpenOrders)
+	endpoint := s.endpoint(delivery.EntryPointMultipleOrders)
 	body := []*rpc.HttpParameter{
-		{Key: "symbol", Val: request.GetSymbol()},
+		{Key: "symbol", Val: request.Symbol},
 	}
 
-	if request.GetRecvWindow() != 0 {
-		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", request.GetRecvWindow())})
+	if ids > 0 {
+		list, err := json.Marshal(request.OrderIdList)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, &rpc.HttpParameter{Key: "orderIdList", Val: string(list)})
+	} else {
+		list, err := json.Marshal(request.OrigClientOrderIdList)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, &rpc.HttpParameter{Key: "origClientOrderIdList", Val: string(list)})
 	}
 
-	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("DELETE"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
+	if request.RecvWindow != 0 {
+		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", request.RecvWindow)})
+	}
 
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("delete"),
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointMultipleOrders))
 
+	return rpc.DoBatch[*pb.CancelOrderResponse](ctx, s.httpclient, req)
+}
+
+// Encode an order of a batch with the parameters of NewOrder,
+// pending is the number of the earlier orders of the batch with the same symbol
+func (s *deliveryTradeService) batchOrder(ctx context.Context, order *pb.BatchOrders, pending int64) (map[string]string, error) {
+	precision, err := s.precision(ctx, order.GetSymbol())
 	if err != nil {
 		return nil, err
 	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
+	quantity, err := parseAmount("quantity", order.GetQuantity())
+	if err != nil {
+		return nil, err
 	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
+	price, err := parseAmount("price", order.GetPrice())
+	if err != nil {
+		return nil, err
+	}
+	stopPrice, err := parseAmount("stopPrice", order.GetStopPrice())
+	if err != nil {
+		return nil, err
+	}
+	activationPrice, err := parseAmount("activationPrice", order.GetActivationPrice())
 	if err != nil {
 		return nil, err
 	}
+	quantity, err = stepQuantity(precision, quantity)
+	if err != nil {
+		return nil, err
+	}
+	price, stopPrice, activationPrice = precision.Price(price), precision.Price(stopPrice), precision.Price(activationPrice)
 
-	out := &pb.CancelAllOpenOrdersResponse{}
+	check := delivery.OrderCheck{
+		Symbol:     order.GetSymbol(),
+		Type:       order.GetType().String(),
+		Quantity:   quantity,
+		Price:      price,
+		StopPrice:  stopPrice,
+		OpenOrders: pending,
+	}
+	if err := s.validate(ctx, check, true); err != nil {
+		return nil, err
+	}
 
-	err = s.m.Unmarshal(respBody, out)
+	params := map[string]string{
+		"symbol": order.GetSymbol(),
+		"side":   order.GetSide().String(),
+		"type":   order.GetType().String(),
+	}
+	if order.GetPositionSide() != 0 {
+		params["positionSide"] = order.GetPositionSide().String()
+	}
+	if !quantity.IsZero() {
+		params["quantity"] = quantity.String()
+	}
+	if order.GetReduceOnly() != "" {
+		params["reduceOnly"] = order.GetReduceOnly()
+	}
+	if !price.IsZero() {
+		params["price"] = price.String()
+	}
+	if order.GetNewClientOrderId() != "" {
+		params["newClientOrderId"] = order.GetNewClientOrderId()
+	} else {
+		params["newClientOrderId"] = s.clientOrderIds.Next()
+	}
+	if !stopPrice.IsZero() {
+		params["stopPrice"] = stopPrice.String()
+	}
+	if order.GetClosePosition() != "" {
+		params["closePosition"] = order.GetClosePosition()
+	}
+	if !activationPrice.IsZero() {
+		params["activationPrice"] = activationPrice.String()
+	}
+	if order.GetCallbackRate() != "" {
+		params["callbackRate"] = order.GetCallbackRate()
+	}
+	if order.GetWorkingType() != 0 {
+		params["workingType"] = order.GetWorkingType().String()
+	}
+	if order.GetPriceProtect() != "" {
+		params["priceProtect"] = order.GetPriceProtect()
+	}
+	if order.GetNewOrderRespType() != 0 {
+		params["newOrderRespType"] = order.GetNewOrderRespType().String()
+	}
+	if order.GetTimeInForce() != 0 {
+		params["timeInForce"] = order.GetTimeInForce().String()
+	}
+	return params, nil
+}
 
+// Encode an order of a batch with the parameters of ModifyOrder
+func (s *deliveryTradeService) modifyBatchOrder(ctx context.Context, order *pb.ModifyBatchOrders) (map[string]string, error) {
+	precision, err := s.precision(ctx, order.GetSymbol())
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
-}
+	quantity, err := amount("quantity", decimal.Decimal{}, order.GetQuantity())
+	if err != nil {
+		return nil, err
+	}
+	price, err := amount("price", decimal.Decimal{}, order.GetPrice())
+	if err != nil {
+		return nil, err
+	}
+	quantity, err = stepQuantity(precision, quantity)
+	if err != nil {
+		return nil, err
+	}
+	price = precision.Price(price)
 
-// Cancel all open orders of the specified symbol at the end of the specified countdown
-func (s *deliveryTradeService) AutoCancelAllOpenOrder(ctx context.Context, request *pb.AutoCancelAllOpenOrdersRequest) (*pb.AutoCancelAllOpenOrdersResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointCountdownCancelAll)
-	body := []*rpc.HttpParameter{
-		{Key: "symbol", Val: request.GetSymbol()},
-		{Key: "countdownTime", Val: fmt.Sprintf("%v", request.GetCountdownTime())},
+	check := delivery.OrderCheck{
+		Symbol:   order.GetSymbol(),
+		Type:     pb.OrderType_LIMIT.String(),
+		Quantity: quantity,
+		Price:    price,
+	}
+	if err := s.validate(ctx, check, false); err != nil {
+		return nil, err
 	}
 
-	if request.GetRecvWindow() != 0 {
-		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", request.GetRecvWindow())})
+	params := map[string]string{
+		"symbol": order.GetSymbol(),
+		"side":   order.GetSide().String(),
+	}
+	if order.GetOrderId() != 0 {
+		params["orderId"] = strconv.FormatInt(order.GetOrderId(), 10)
 	}
+	if order.GetOrigClientOrderId() != "" {
+		params["origClientOrderId"] = order.GetOrigClientOrderId()
+	}
+	if !quantity.IsZero() {
+		params["quantity"] = quantity.String()
+	}
+	if !price.IsZero() {
+		params["price"] = price.String()
+	}
+	return params, nil
+}
 
-	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("POST"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
+// Orders of a batch request, the orders rejected locally keep their error in their slot
+type batch[T any] struct {
+	results []rpc.BatchResult[T]
+	orders  []map[string]string
+	slots   []int
+}
 
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+func newBatch[T any](size int) *batch[T] {
+	return &batch[T]{results: make([]rpc.BatchResult[T], size)}
+}
 
+func (b *batch[T]) add(slot int, order map[string]string, err error) {
 	if err != nil {
-		return nil, err
+		b.results[slot].Err = err
+		return
 	}
+	b.orders = append(b.orders, order)
+	b.slots = append(b.slots, slot)
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
+// Send the orders of a batch which are not rejected locally and merge the results into their slots
+func sendBatch[T proto.Message](ctx context.Context, s *deliveryTradeService, b *batch[T], method string, recvWindow int64) ([]rpc.BatchResult[T], error) {
+	if len(b.orders) == 0 {
+		return b.results, nil
 	}
 
-	respBody, err := ioutil.ReadAll(resp.Body)
+	batchOrders, err := json.Marshal(b.orders)
 	if err != nil {
 		return nil, err
 	}
 
-	out := &pb.AutoCancelAllOpenOrdersResponse{}
+	endpoint := s.endpoint(delivery.EntryPointMultipleOrders)
+	body := []*rpc.HttpParameter{
+		{Key: "batchOrders", Val: string(batchOrders)},
+	}
 
-	err = s.m.Unmarshal(respBody, out)
+	if recvWindow != 0 {
+		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", recvWindow)})
+	}
 
+	opts := []rpc.RequestOption{rpc.SetEndpoint(endpoint), rpc.SetMethod(method),
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointMultipleOrders)}
+	if method == http.MethodPost {
+		opts = append(opts, rpc.SetOrderCount(int64(len(b.orders))))
+	}
+	req := s.httpclient.GetHttpRequest(opts...)
+
+	results, err := rpc.DoBatch[T](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	if len(results) != len(b.slots) {
+		return nil, fmt.Errorf("got %d results for %d batch orders", len(results), len(b.slots))
+	}
+
+	for i, result := range results {
+		b.results[b.slots[i]] = result
+	}
+	return b.results, nil
 }
 
-// Change user's initial leverage in the specific symbol market.
-// For Hedge Mode, LONG and SHORT positions of one symbol use
-// the same initial leverage and share a total notional value.
-func (s *deliveryTradeService) ChangeInitialLeverage(ctx context.Context, request *pb.ChangeInitialLeverageRequest) (*pb.ChangeInitialLeverageResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointLeverage)
+// Cancel All Open Orders
+func (s *deliveryTradeService) CancelAllOpenOrders(ctx context.Context, request *pb.CancelAllOpenOrdersRequest) (*pb.CancelAllOpenOrdersResponse, error) {
+	endpoint := s.endpoint(delivery.EntryPointAllOpenOrders)
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
-		{Key: "leverage", Val: fmt.Sprintf("%v", request.GetLeverage())},
 	}
 
 	if request.GetRecvWindow() != 0 {
 		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", request.GetRecvWindow())})
 	}
 
-	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("POST"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
+	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("DELETE"),
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointAllOpenOrders))
 
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+	return rpc.Do[*pb.CancelAllOpenOrdersResponse](ctx, s.httpclient, req)
+}
 
-	if err != nil {
-		return nil, err
+// Cancel all open orders of the specified symbol at the end of the specified countdown
+func (s *deliveryTradeService) AutoCancelAllOpenOrder(ctx context.Context, request *pb.AutoCancelAllOpenOrdersRequest) (*pb.AutoCancelAllOpenOrdersResponse, error) {
+	endpoint := s.endpoint(delivery.EntryPointCountdownCancelAll)
+	body := []*rpc.HttpParameter{
+		{Key: "symbol", Val: request.GetSymbol()},
+		{Key: "countdownTime", Val: fmt.Sprintf("%v", request.GetCountdownTime())},
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
+	if request.GetRecvWindow() != 0 {
+		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", request.GetRecvWindow())})
 	}
 
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("POST"),
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointCountdownCancelAll))
 
-	out := &pb.ChangeInitialLeverageResponse{}
+	return rpc.Do[*pb.AutoCancelAllOpenOrdersResponse](ctx, s.httpclient, req)
+}
 
-	err = s.m.Unmarshal(respBody, out)
+// Change user's initial leverage in the specific symbol market.
+// For Hedge Mode, LONG and SHORT positions of one symbol use
+// the same initial leverage and share a total notional value.
+func (s *deliveryTradeService) ChangeInitialLeverage(ctx context.Context, request *pb.ChangeInitialLeverageRequest) (*pb.ChangeInitialLeverageResponse, error) {
+	endpoint := s.endpoint(delivery.EntryPointLeverage)
+	body := []*rpc.HttpParameter{
+		{Key: "symbol", Val: request.GetSymbol()},
+		{Key: "leverage", Val: fmt.Sprintf("%v", request.GetLeverage())},
+	}
 
-	if err != nil {
-		return nil, err
+	if request.GetRecvWindow() != 0 {
+		body = append(body, &rpc.HttpParameter{Key: "recvWindow", Val: fmt.Sprintf("%v", request.GetRecvWindow())})
 	}
-	return out, nil
+
+	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("POST"),
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointLeverage))
+
+	return rpc.Do[*pb.ChangeInitialLeverageResponse](ctx, s.httpclient, req)
 }
 
 // Change user's margin type in the specific symbol market.
@@ -498,7 +714,7 @@ func (s *deliveryTradeService) ChangeInitialLeverage(ctx context.Context, reques
 // With ISOLATED margin type, margins of
 // the LONG and SHORT positions are isolated from each other.
 func (s *deliveryTradeService) ChangeMarginType(ctx context.Context, request *pb.ChangeMarginTypeRequest) (*pb.ChangeMarginTypeResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointMarginType)
+	endpoint := s.endpoint(delivery.EntryPointMarginType)
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
 		{Key: "marginType", Val: request.GetMarginType().String()},
@@ -509,39 +725,17 @@ func (s *deliveryTradeService) ChangeMarginType(ctx context.Context, request *pb
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("POST"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointMarginType))
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.ChangeMarginTypeResponse{}
-
-	err = s.m.Unmarshal(respBody, out)
-
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+	return rpc.Do[*pb.ChangeMarginTypeResponse](ctx, s.httpclient, req)
 }
 
 // Modify Isolated Position Margin
 func (s *deliveryTradeService) ModifyIsolatedPositionMargin(ctx context.Context, request *pb.ModifyIsolatedPositionMarginRequest) (*pb.ModifyIsolatedPositionMarginResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointPositionMargin)
+	endpoint := s.endpoint(delivery.EntryPointPositionMargin)
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
-		{Key: "amount", Val: fmt.Sprintf("%v", request.GetAmount())},
+		{Key: "amount", Val: strconv.FormatFloat(request.GetAmount(), 'f', -1, 64)},
 		{Key: "type", Val: fmt.Sprintf("%v", request.GetType())},
 	}
 
@@ -554,29 +748,87 @@ func (s *deliveryTradeService) ModifyIsolatedPositionMargin(ctx context.Context,
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("POST"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointPositionMargin))
 
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+	return rpc.Do[*pb.ModifyIsolatedPositionMarginResponse](ctx, s.httpclient, req)
+}
 
+// Get the exact value of an amount, the decimal takes precedence over the float64 of the request
+func amount(name string, exact decimal.Decimal, value float64) (decimal.Decimal, error) {
+	if !exact.IsZero() {
+		return exact, nil
+	}
+	d, err := decimal.FromFloat(value)
 	if err != nil {
-		return nil, err
+		return decimal.Decimal{}, fmt.Errorf("%s: %w", name, err)
 	}
+	return d, nil
+}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
+// Get the precision of a symbol, the one of WithPrecision takes precedence over the one of exchangeInfo
+// which is used when the orders are validated
+func (s *deliveryTradeService) precision(ctx context.Context, symbol string) (delivery.Precision, error) {
+	if p, ok := s.precisions[symbol]; ok || s.rules == nil {
+		return p, nil
 	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
+	info, err := s.rules.Symbol(ctx, symbol)
 	if err != nil {
-		return nil, err
+		return delivery.Precision{}, err
+	}
+	return info.Precision(), nil
+}
+
+// Load exchangeInfo of the order validation again
+func (s *deliveryTradeService) ReloadExchangeInfo(ctx context.Context) error {
+	if s.rules == nil {
+		return nil
+	}
+	return s.rules.Reload(ctx)
+}
+
+// Truncate a quantity to the step size, a quantity below the step size is rejected rather than dropped from the order
+func stepQuantity(precision delivery.Precision, quantity decimal.Decimal) (decimal.Decimal, error) {
+	stepped := precision.Quantity(quantity)
+	if stepped.IsZero() && !quantity.IsZero() {
+		return decimal.Decimal{}, fmt.Errorf("quantity %s is below the step size %s", quantity, precision.StepSize)
+	}
+	return stepped, nil
+}
+
+// Check an order against exchangeInfo when the validation is enabled,
+// the open orders are only counted for a new order and added to the OpenOrders of the check
+func (s *deliveryTradeService) validate(ctx context.Context, order delivery.OrderCheck, newOrder bool) error {
+	if s.rules == nil {
+		return nil
+	}
+
+	if s.markPrice != nil && !order.Price.IsZero() {
+		markPrice, err := s.markPrice(ctx, order.Symbol)
+		if err != nil {
+			return fmt.Errorf("get mark price of %s: %w", order.Symbol, err)
+		}
+		order.MarkPrice = markPrice
 	}
 
-	out := &pb.ModifyIsolatedPositionMarginResponse{}
+	if s.openOrderCount != nil && newOrder {
+		count, err := s.openOrderCount(ctx, order.Symbol)
+		if err != nil {
+			return fmt.Errorf("count open orders of %s: %w", order.Symbol, err)
+		}
+		order.OpenOrders += count
+	}
 
-	err = s.m.Unmarshal(respBody, out)
+	return s.rules.Validate(ctx, order)
+}
 
+// Parse an amount of a batch order, an empty amount is zero
+func parseAmount(name, value string) (decimal.Decimal, error) {
+	if value == "" {
+		return decimal.Decimal{}, nil
+	}
+	d, err := decimal.Parse(value)
 	if err != nil {
-		return nil, err
+		return decimal.Decimal{}, fmt.Errorf("%s: %w", name, err)
 	}
-	return out, nil
+	return d, nil
 }
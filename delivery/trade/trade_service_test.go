@@ -3,18 +3,29 @@ package trade
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	pb "github.com/h9896/bingo-pkg-protobuf/services/delivery/v1"
+	"github.com/h9896/bingo/decimal"
+	"github.com/h9896/bingo/delivery"
 	"github.com/h9896/bingo/mocks"
+	"github.com/h9896/bingo/profile"
+	"github.com/h9896/bingo/rpc"
 	"github.com/stretchr/testify/assert"
 )
 
-func getMockDeliveryTradeService() pb.DeliveryTradeServiceServer {
-	return NewDeliveryTradeService(mocks.MockDomain, mocks.MockApiKey, mocks.MockSecret, true, &mocks.MockHTTPClient{})
+func getMockDeliveryTradeService() DeliveryTradeService {
+	return NewDeliveryTradeService(mocks.MockDomain, mocks.MockApiKey, rpc.NewHMACSigner(mocks.MockSecret), true, &mocks.MockHTTPClient{})
 }
 
 func TestChangePositionMode(t *testing.T) {
@@ -395,3 +406,713 @@ func TestModifyIsolatedPositionMargin(t *testing.T) {
 	assert.EqualValues(t, 100.0, resp.Amount)
 	assert.EqualValues(t, "Successfully modify position margin.", resp.GetMsg())
 }
+
+func TestNewOrderAPIError(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	request := &pb.NewOrderRequest{
+		Symbol:   "BTCUSD_PERP",
+		Side:     pb.OrderSide_BUY,
+		Type:     pb.OrderType_MARKET,
+		Quantity: 1,
+	}
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		data := `{
+			"code": -2019,
+			"msg": "Margin is insufficient."
+		}`
+		resp = &http.Response{StatusCode: 400, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+	resp, err := service.NewOrder(context.Background(), request)
+	assert.Nil(t, resp)
+
+	apiErr := &rpc.APIError{}
+	assert.True(t, errors.As(err, &apiErr))
+	assert.EqualValues(t, 400, apiErr.StatusCode)
+	assert.EqualValues(t, -2019, apiErr.Code)
+	assert.EqualValues(t, "Margin is insufficient.", apiErr.Message)
+	assert.EqualValues(t, fmt.Sprintf("%s/dapi/v1/order", mocks.MockDomain), apiErr.Endpoint)
+}
+
+func TestNewDeliveryTradeServiceWithProfile(t *testing.T) {
+	// The scheme is taken from the profile, a mock server without TLS uses http
+	p := profile.Custom("mock", profile.Endpoints{RestDomain: mocks.MockDomain}, profile.Endpoints{}, profile.Endpoints{})
+	service := NewDeliveryTradeServiceWithProfile(p, mocks.MockApiKey, rpc.NewHMACSigner(mocks.MockSecret), &mocks.MockHTTPClient{})
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.EqualValues(t, "http", req.URL.Scheme)
+		assert.EqualValues(t, mocks.MockDomain, req.URL.Host)
+		assert.EqualValues(t, "/dapi/v1/leverage", req.URL.Path)
+		data := `{
+			"leverage": 21,
+			"maxQty": "1000",
+			"symbol": "BTCUSD_200925"
+		}`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+	resp, err := service.ChangeInitialLeverage(context.Background(), &pb.ChangeInitialLeverageRequest{Symbol: "BTCUSD_200925", Leverage: 21})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 21, resp.Leverage)
+
+	// The entry points are built with the API prefix of the profile
+	p = profile.Custom("proxy", profile.Endpoints{RestDomain: mocks.MockDomain, APIPrefix: "binance/dapi/v1"}, profile.Endpoints{}, profile.Endpoints{})
+	service = NewDeliveryTradeServiceWithProfile(p, mocks.MockApiKey, rpc.NewHMACSigner(mocks.MockSecret), &mocks.MockHTTPClient{})
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.EqualValues(t, "/binance/dapi/v1/leverage", req.URL.Path)
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"leverage": 21}`))}
+		return
+	}
+	_, err = service.ChangeInitialLeverage(context.Background(), &pb.ChangeInitialLeverageRequest{Symbol: "BTCUSD_200925", Leverage: 21})
+	assert.Nil(t, err)
+}
+
+func TestNewDeliveryTradeServiceWithOptions(t *testing.T) {
+	_, err := NewDeliveryTradeServiceWithOptions(delivery.WithEnvironment(profile.Testnet), delivery.WithAPIKey(mocks.MockApiKey))
+	assert.True(t, errors.Is(err, delivery.ErrInvalidConfig))
+
+	service, err := NewDeliveryTradeServiceWithOptions(delivery.WithDomain(mocks.MockDomain, true),
+		delivery.WithAPIKey(mocks.MockApiKey), delivery.WithSigner(rpc.NewHMACSigner(mocks.MockSecret)),
+		delivery.WithHTTPClient(&mocks.MockHTTPClient{}), delivery.WithRecvWindow(3*time.Second))
+	assert.Nil(t, err)
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		mocks.CheckHeader(t, req.Header)
+		params := req.URL.Query()
+		mocks.CheckTimestampAndSignature(t, params)
+		assert.EqualValues(t, "3000", params.Get("recvWindow"))
+		data := `{
+			"leverage": 21,
+			"maxQty": "1000",
+			"symbol": "BTCUSD_200925"
+		}`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+	resp, err := service.ChangeInitialLeverage(context.Background(), &pb.ChangeInitialLeverageRequest{Symbol: "BTCUSD_200925", Leverage: 21})
+	assert.Nil(t, err)
+	assert.EqualValues(t, 21, resp.Leverage)
+}
+
+func TestNewOrderDecimalFormatting(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	request := &pb.NewOrderRequest{
+		Symbol:    "BTCUSD_200925",
+		Side:      pb.OrderSide_BUY,
+		Type:      pb.OrderType_STOP,
+		Quantity:  1e-05,
+		Price:     598.2,
+		StopPrice: 1e21,
+	}
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		params := req.URL.Query()
+		assert.EqualValues(t, "0.00001", params.Get("quantity"))
+		assert.EqualValues(t, "598.2", params.Get("price"))
+		assert.EqualValues(t, "1000000000000000000000", params.Get("stopPrice"))
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"orderId": 1}`))}
+		return
+	}
+	_, err := service.NewOrder(context.Background(), request)
+	assert.Nil(t, err)
+}
+
+func TestNewOrderWithAmounts(t *testing.T) {
+	service, err := NewDeliveryTradeServiceWithOptions(delivery.WithDomain(mocks.MockDomain, true),
+		delivery.WithAPIKey(mocks.MockApiKey), delivery.WithSigner(rpc.NewHMACSigner(mocks.MockSecret)),
+		delivery.WithHTTPClient(&mocks.MockHTTPClient{}),
+		delivery.WithPrecision("BTCUSD_200925", delivery.Precision{TickSize: decimal.MustParse("0.1"), StepSize: decimal.MustParse("1")}))
+	assert.Nil(t, err)
+
+	request := &pb.NewOrderRequest{
+		Symbol:   "BTCUSD_200925",
+		Side:     pb.OrderSide_BUY,
+		Type:     pb.OrderType_LIMIT,
+		Quantity: 2.9,
+		Price:    598.2,
+	}
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		params := req.URL.Query()
+		assert.EqualValues(t, "2", params.Get("quantity"))
+		assert.EqualValues(t, "9300.6", params.Get("price"))
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"orderId": 1}`))}
+		return
+	}
+	_, err = service.NewOrderWithAmounts(context.Background(), request, Amounts{Price: decimal.MustParse("9300.55")})
+	assert.Nil(t, err)
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		params := req.URL.Query()
+		assert.EqualValues(t, "7", params.Get("quantity"))
+		assert.EqualValues(t, "0.3", params.Get("price"))
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"orderId": 1}`))}
+		return
+	}
+	// The binary float artifacts are rounded to the tick size
+	tenth, fifth := 0.1, 0.2
+	_, err = service.ModifyOrderWithAmounts(context.Background(), &pb.ModifyOrderRequest{
+		Symbol:  "BTCUSD_200925",
+		Side:    pb.OrderSide_BUY,
+		OrderId: 1,
+		Price:   tenth + fifth,
+	}, Amounts{Quantity: decimal.MustParse("7.5")})
+	assert.Nil(t, err)
+}
+
+func TestNewOrderQuantityBelowStep(t *testing.T) {
+	service, err := NewDeliveryTradeServiceWithOptions(delivery.WithDomain(mocks.MockDomain, true),
+		delivery.WithAPIKey(mocks.MockApiKey), delivery.WithSigner(rpc.NewHMACSigner(mocks.MockSecret)),
+		delivery.WithHTTPClient(&mocks.MockHTTPClient{}),
+		delivery.WithPrecision("BTCUSD_200925", delivery.Precision{TickSize: decimal.MustParse("0.1"), StepSize: decimal.MustParse("1")}))
+	assert.Nil(t, err)
+
+	sent := 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		sent++
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`[]`))}
+		return
+	}
+
+	_, err = service.NewOrder(context.Background(), &pb.NewOrderRequest{
+		Symbol:   "BTCUSD_200925",
+		Side:     pb.OrderSide_BUY,
+		Type:     pb.OrderType_LIMIT,
+		Quantity: 0.5,
+		Price:    598.2,
+	})
+	assert.EqualError(t, err, "quantity 0.5 is below the step size 1")
+
+	_, err = service.ModifyOrder(context.Background(), &pb.ModifyOrderRequest{
+		Symbol:   "BTCUSD_200925",
+		Side:     pb.OrderSide_BUY,
+		OrderId:  1,
+		Quantity: 0.5,
+		Price:    598.2,
+	})
+	assert.EqualError(t, err, "quantity 0.5 is below the step size 1")
+
+	results, err := service.PlaceMultipleOrders(context.Background(), &pb.PlaceMultipleOrdersRequest{
+		BatchOrders: []*pb.BatchOrders{
+			{Symbol: "BTCUSD_200925", Side: pb.OrderSide_BUY, Type: pb.OrderType_LIMIT, Quantity: "0.5", Price: "598.2"},
+		},
+	})
+	assert.Nil(t, err)
+	assert.EqualError(t, results[0].Err, "quantity 0.5 is below the step size 1")
+	assert.EqualValues(t, 0, sent)
+}
+
+func TestNewOrderWithValidation(t *testing.T) {
+	openOrders := int64(0)
+	service, err := NewDeliveryTradeServiceWithOptions(delivery.WithDomain(mocks.MockDomain, true),
+		delivery.WithAPIKey(mocks.MockApiKey), delivery.WithSigner(rpc.NewHMACSigner(mocks.MockSecret)),
+		delivery.WithHTTPClient(&mocks.MockHTTPClient{}),
+		delivery.WithOpenOrderCount(func(ctx context.Context, symbol string) (int64, error) {
+			return openOrders, nil
+		}))
+	assert.Nil(t, err)
+
+	paths, prices := []string{}, []string{}
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		paths = append(paths, req.URL.Path)
+		prices = append(prices, req.URL.Query().Get("price"))
+		data := `{"orderId": 1}`
+		if req.URL.Path == "/dapi/v1/exchangeInfo" {
+			data = `{
+				"symbols": [{
+					"symbol": "BTCUSD_200925",
+					"filters": [
+						{"filterType": "PRICE_FILTER", "maxPrice": "100000", "minPrice": "0.1", "tickSize": "0.1"},
+						{"filterType": "LOT_SIZE", "maxQty": "100000", "minQty": "1", "stepSize": "1"},
+						{"filterType": "MAX_NUM_ORDERS", "limit": 200}
+					]
+				}]
+			}`
+		}
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	request := &pb.NewOrderRequest{
+		Symbol:   "BTCUSD_200925",
+		Side:     pb.OrderSide_BUY,
+		Type:     pb.OrderType_LIMIT,
+		Quantity: 1,
+		Price:    100000.5,
+	}
+	_, err = service.NewOrder(context.Background(), request)
+	var validationErr *delivery.ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.EqualValues(t, delivery.FilterPrice, validationErr.Filter)
+	assert.EqualValues(t, []string{"/dapi/v1/exchangeInfo"}, paths)
+
+	// Without WithPrecision the price is rounded to the tick size of exchangeInfo
+	request.Price = 9300.55
+	_, err = service.NewOrder(context.Background(), request)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"/dapi/v1/exchangeInfo", "/dapi/v1/order"}, paths)
+	assert.EqualValues(t, "9300.6", prices[1])
+
+	openOrders = 200
+	_, err = service.NewOrder(context.Background(), request)
+	assert.True(t, errors.As(err, &validationErr))
+	assert.EqualValues(t, delivery.FilterMaxNumOrders, validationErr.Filter)
+
+	// A modification does not add an open order
+	_, err = service.ModifyOrder(context.Background(), &pb.ModifyOrderRequest{
+		Symbol: "BTCUSD_200925", Side: pb.OrderSide_BUY, OrderId: 1, Quantity: 200000, Price: 9300.5,
+	})
+	assert.True(t, errors.As(err, &validationErr))
+	assert.EqualValues(t, delivery.FilterLotSize, validationErr.Filter)
+	assert.EqualValues(t, []string{"/dapi/v1/exchangeInfo", "/dapi/v1/order"}, paths)
+
+	assert.Nil(t, service.ReloadExchangeInfo(context.Background()))
+	assert.EqualValues(t, []string{"/dapi/v1/exchangeInfo", "/dapi/v1/order", "/dapi/v1/exchangeInfo"}, paths)
+}
+
+func TestPlaceMultipleOrders(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	request := &pb.PlaceMultipleOrdersRequest{
+		BatchOrders: []*pb.BatchOrders{
+			{Symbol: "BTCUSD_200925", Side: pb.OrderSide_BUY, Type: pb.OrderType_LIMIT, TimeInForce: pb.TimeInForce_GTC, Quantity: "1", Price: "9300.5"},
+			{Symbol: "BTCUSD_200925", Side: pb.OrderSide_SELL, Type: pb.OrderType_LIMIT, Quantity: "1e-5", Price: "9400"},
+			{Symbol: "BTCUSD_200925", Side: pb.OrderSide_SELL, Type: pb.OrderType_MARKET, Quantity: "2", ReduceOnly: "true"},
+		},
+		RecvWindow: 5000,
+	}
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.EqualValues(t, http.MethodPost, req.Method)
+		mocks.CheckHeader(t, req.Header)
+		assert.EqualValues(t, "/dapi/v1/batchOrders", req.URL.Path)
+		params := req.URL.Query()
+		mocks.CheckTimestampAndSignature(t, params)
+		assert.EqualValues(t, "5000", params.Get("recvWindow"))
+
+		// The order rejected locally is not sent
+		orders := []map[string]string{}
+		assert.Nil(t, json.Unmarshal([]byte(params.Get("batchOrders")), &orders))
+		// The orders without a client order id get a generated one
+		for _, order := range orders {
+			assert.NotEmpty(t, order["newClientOrderId"])
+			delete(order, "newClientOrderId")
+		}
+		assert.EqualValues(t, []map[string]string{
+			{"symbol": "BTCUSD_200925", "side": "BUY", "type": "LIMIT", "timeInForce": "GTC", "quantity": "1", "price": "9300.5"},
+			{"symbol": "BTCUSD_200925", "side": "SELL", "type": "MARKET", "quantity": "2", "reduceOnly": "true"},
+		}, orders)
+
+		data := `[
+			{
+				"clientOrderId": "testOrder",
+				"orderId": 22542179,
+				"origQty": "1",
+				"price": "9300.5",
+				"side": "BUY",
+				"status": "NEW",
+				"symbol": "BTCUSD_200925",
+				"timeInForce": "GTC",
+				"type": "LIMIT"
+			},
+			{
+				"code": -2022,
+				"msg": "ReduceOnly Order is rejected."
+			}
+		]`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	results, err := service.PlaceMultipleOrders(context.Background(), request)
+	assert.Nil(t, err)
+	assert.Len(t, results, 3)
+
+	assert.Nil(t, results[0].Err)
+	assert.EqualValues(t, 22542179, results[0].Value.OrderId)
+
+	assert.NotNil(t, results[1].Err)
+	assert.Contains(t, results[1].Err.Error(), "quantity")
+
+	var apiErr *rpc.APIError
+	assert.True(t, errors.As(results[2].Err, &apiErr))
+	assert.EqualValues(t, -2022, apiErr.Code)
+	assert.EqualValues(t, "ReduceOnly Order is rejected.", apiErr.Message)
+}
+
+func TestPlaceMultipleOrdersOpenOrderLimit(t *testing.T) {
+	service, err := NewDeliveryTradeServiceWithOptions(delivery.WithDomain(mocks.MockDomain, true),
+		delivery.WithAPIKey(mocks.MockApiKey), delivery.WithSigner(rpc.NewHMACSigner(mocks.MockSecret)),
+		delivery.WithHTTPClient(&mocks.MockHTTPClient{}),
+		delivery.WithOpenOrderCount(func(ctx context.Context, symbol string) (int64, error) {
+			return 199, nil
+		}))
+	assert.Nil(t, err)
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		data := `[{"orderId": 1}]`
+		if req.URL.Path == "/dapi/v1/exchangeInfo" {
+			data = `{
+				"symbols": [{
+					"symbol": "BTCUSD_200925",
+					"filters": [{"filterType": "MAX_NUM_ORDERS", "limit": 200}]
+				}]
+			}`
+		} else {
+			orders := []map[string]string{}
+			assert.Nil(t, json.Unmarshal([]byte(req.URL.Query().Get("batchOrders")), &orders))
+			assert.Len(t, orders, 1)
+		}
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	// Only one slot is left, so the later orders of the batch are rejected
+	order := &pb.BatchOrders{Symbol: "BTCUSD_200925", Side: pb.OrderSide_BUY, Type: pb.OrderType_LIMIT, Quantity: "1", Price: "9300.5"}
+	results, err := service.PlaceMultipleOrders(context.Background(), &pb.PlaceMultipleOrdersRequest{
+		BatchOrders: []*pb.BatchOrders{order, order, order, order, order},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, results, 5)
+	assert.Nil(t, results[0].Err)
+	for _, result := range results[1:] {
+		var validationErr *delivery.ValidationError
+		assert.True(t, errors.As(result.Err, &validationErr))
+		assert.EqualValues(t, delivery.FilterMaxNumOrders, validationErr.Filter)
+	}
+}
+
+func TestPlaceMultipleOrdersLimit(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		t.Fatal("an invalid batch is sent")
+		return
+	}
+
+	_, err := service.PlaceMultipleOrders(context.Background(), &pb.PlaceMultipleOrdersRequest{})
+	assert.NotNil(t, err)
+
+	orders := make([]*pb.BatchOrders, MaxBatchOrders+1)
+	for i := range orders {
+		orders[i] = &pb.BatchOrders{Symbol: "BTCUSD_200925", Side: pb.OrderSide_BUY, Type: pb.OrderType_MARKET, Quantity: "1"}
+	}
+	_, err = service.PlaceMultipleOrders(context.Background(), &pb.PlaceMultipleOrdersRequest{BatchOrders: orders})
+	assert.NotNil(t, err)
+}
+
+func TestModifyMultipleOrders(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	request := &pb.ModifyMultipleOrdersRequest{
+		BatchOrders: []*pb.ModifyBatchOrders{
+			{Symbol: "BTCUSD_200925", Side: pb.OrderSide_BUY, OrderId: 22542179, Quantity: 2, Price: 9300.5},
+			{Symbol: "BTCUSD_200925", Side: pb.OrderSide_SELL, OrigClientOrderId: "abc", Quantity: 1e-05, Price: 9400},
+		},
+	}
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.EqualValues(t, http.MethodPut, req.Method)
+		assert.EqualValues(t, "/dapi/v1/batchOrders", req.URL.Path)
+		params := req.URL.Query()
+		mocks.CheckTimestampAndSignature(t, params)
+
+		orders := []map[string]string{}
+		assert.Nil(t, json.Unmarshal([]byte(params.Get("batchOrders")), &orders))
+		assert.EqualValues(t, []map[string]string{
+			{"symbol": "BTCUSD_200925", "side": "BUY", "orderId": "22542179", "quantity": "2", "price": "9300.5"},
+			{"symbol": "BTCUSD_200925", "side": "SELL", "origClientOrderId": "abc", "quantity": "0.00001", "price": "9400"},
+		}, orders)
+
+		data := `[
+			{"code": -1111, "msg": "Precision is over the maximum defined for this asset."},
+			{"orderId": 20072994037, "symbol": "BTCUSD_200925", "status": "NEW", "clientOrderId": "abc", "price": "9400"}
+		]`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	results, err := service.ModifyMultipleOrders(context.Background(), request)
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+
+	var apiErr *rpc.APIError
+	assert.True(t, errors.As(results[0].Err, &apiErr))
+	assert.EqualValues(t, -1111, apiErr.Code)
+	assert.Nil(t, results[1].Err)
+	assert.EqualValues(t, 20072994037, results[1].Value.OrderId)
+}
+
+func TestCancelMultipleOrders(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	request := &CancelMultipleOrdersRequest{
+		Symbol:      "BTCUSD_200925",
+		OrderIdList: []int64{283194212, 283194213},
+		RecvWindow:  5000,
+	}
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.EqualValues(t, http.MethodDelete, req.Method)
+		mocks.CheckHeader(t, req.Header)
+		assert.EqualValues(t, "/dapi/v1/batchOrders", req.URL.Path)
+		params := req.URL.Query()
+		mocks.CheckTimestampAndSignature(t, params)
+		assert.EqualValues(t, "BTCUSD_200925", params.Get("symbol"))
+		assert.EqualValues(t, "[283194212,283194213]", params.Get("orderIdList"))
+		assert.EqualValues(t, "", params.Get("origClientOrderIdList"))
+		assert.EqualValues(t, "5000", params.Get("recvWindow"))
+		data := `[
+			{
+				"clientOrderId": "myOrder1",
+				"orderId": 283194212,
+				"origQty": "11",
+				"price": "0",
+				"side": "BUY",
+				"status": "CANCELED",
+				"symbol": "BTCUSD_200925",
+				"type": "TRAILING_STOP_MARKET"
+			},
+			{
+				"code": -2011,
+				"msg": "Unknown order sent."
+			}
+		]`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	results, err := service.CancelMultipleOrders(context.Background(), request)
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+	assert.Nil(t, results[0].Err)
+	assert.EqualValues(t, "CANCELED", results[0].Value.Status.String())
+
+	var apiErr *rpc.APIError
+	assert.True(t, errors.As(results[1].Err, &apiErr))
+	assert.EqualValues(t, -2011, apiErr.Code)
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		params := req.URL.Query()
+		assert.EqualValues(t, `["myOrder1","myOrder2"]`, params.Get("origClientOrderIdList"))
+		assert.EqualValues(t, "", params.Get("orderIdList"))
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`[]`))}
+		return
+	}
+	_, err = service.CancelMultipleOrders(context.Background(), &CancelMultipleOrdersRequest{
+		Symbol:                "BTCUSD_200925",
+		OrigClientOrderIdList: []string{"myOrder1", "myOrder2"},
+	})
+	assert.Nil(t, err)
+}
+
+func TestCancelMultipleOrdersInvalid(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		t.Fatal("an invalid request is sent")
+		return
+	}
+
+	_, err := service.CancelMultipleOrders(context.Background(), &CancelMultipleOrdersRequest{Symbol: "BTCUSD_200925"})
+	assert.NotNil(t, err)
+
+	_, err = service.CancelMultipleOrders(context.Background(), &CancelMultipleOrdersRequest{
+		Symbol: "BTCUSD_200925", OrderIdList: []int64{1}, OrigClientOrderIdList: []string{"myOrder1"},
+	})
+	assert.NotNil(t, err)
+
+	_, err = service.CancelMultipleOrders(context.Background(), &CancelMultipleOrdersRequest{
+		Symbol: "BTCUSD_200925", OrderIdList: make([]int64, MaxCancelOrders+1),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestNewOrderClientOrderId(t *testing.T) {
+	service, err := NewDeliveryTradeServiceWithOptions(delivery.WithDomain(mocks.MockDomain, true),
+		delivery.WithAPIKey(mocks.MockApiKey), delivery.WithSigner(rpc.NewHMACSigner(mocks.MockSecret)),
+		delivery.WithHTTPClient(&mocks.MockHTTPClient{}), delivery.WithClientOrderIdPrefix("bot1-"))
+	assert.Nil(t, err)
+
+	var sent string
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		sent = req.URL.Query().Get("newClientOrderId")
+		data := fmt.Sprintf(`{"clientOrderId": %q, "orderId": 22542179, "status": "NEW"}`, sent)
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+	resp, err := service.NewOrder(context.Background(), &pb.NewOrderRequest{Symbol: "BTCUSD_PERP", Side: pb.OrderSide_BUY, Type: pb.OrderType_MARKET, Quantity: 1})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(sent, "bot1-"))
+	assert.EqualValues(t, sent, resp.ClientOrderId)
+}
+
+func TestNewOrderReconcile(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	request := &pb.NewOrderRequest{Symbol: "BTCUSD_PERP", Side: pb.OrderSide_BUY, Type: pb.OrderType_MARKET, Quantity: 1, NewClientOrderId: "abc"}
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		params := req.URL.Query()
+		if req.Method == http.MethodPost {
+			assert.EqualValues(t, "abc", params.Get("newClientOrderId"))
+			data := `{"code": -1007, "msg": "Timeout waiting for response from backend server. Send status unknown; execution status unknown."}`
+			resp = &http.Response{StatusCode: 408, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+			return
+		}
+
+		// The order is queried by its client order id
+		assert.EqualValues(t, http.MethodGet, req.Method)
+		assert.EqualValues(t, "/dapi/v1/order", req.URL.Path)
+		mocks.CheckTimestampAndSignature(t, params)
+		assert.EqualValues(t, "abc", params.Get("origClientOrderId"))
+		data := `{
+			"avgPrice": "0.0",
+			"clientOrderId": "abc",
+			"cumBase": "0",
+			"executedQty": "0",
+			"orderId": 1917641,
+			"origQty": "1",
+			"origType": "MARKET",
+			"price": "0",
+			"side": "BUY",
+			"status": "NEW",
+			"symbol": "BTCUSD_PERP",
+			"pair": "BTCUSD",
+			"time": 1579276756075,
+			"type": "MARKET",
+			"updateTime": 1579276756075
+		}`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+	resp, err := service.NewOrder(context.Background(), request)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1917641, resp.OrderId)
+	assert.EqualValues(t, "abc", resp.ClientOrderId)
+	assert.EqualValues(t, "NEW", resp.Status.String())
+}
+
+func TestNewOrderReconcileNotPlaced(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	request := &pb.NewOrderRequest{Symbol: "BTCUSD_PERP", Side: pb.OrderSide_BUY, Type: pb.OrderType_MARKET, Quantity: 1}
+
+	posts, queries := 0, 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		if req.Method == http.MethodPost {
+			posts++
+			return nil, &url.Error{Op: "Post", URL: req.URL.String(), Err: syscall.ECONNRESET}
+		}
+		queries++
+		data := `{"code": -2013, "msg": "Order does not exist."}`
+		resp = &http.Response{StatusCode: 400, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+	resp, err := service.NewOrder(context.Background(), request)
+	assert.Nil(t, resp)
+	// The order is not sent again and keeps its client order id
+	assert.EqualValues(t, 1, posts)
+	assert.EqualValues(t, reconcileAttempts, queries)
+	statusErr := &OrderStatusError{}
+	assert.True(t, errors.As(err, &statusErr))
+	assert.NotEmpty(t, statusErr.ClientOrderId)
+	apiErr := &rpc.APIError{}
+	assert.True(t, errors.As(statusErr.QueryErr, &apiErr))
+	assert.EqualValues(t, -2013, apiErr.Code)
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+
+	// The status stays unknown when the order cannot be queried
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		if req.Method == http.MethodPost {
+			return nil, &url.Error{Op: "Post", URL: req.URL.String(), Err: syscall.ECONNRESET}
+		}
+		return nil, errors.New("network is unreachable")
+	}
+	resp, err = service.NewOrder(context.Background(), request)
+	assert.Nil(t, resp)
+	assert.True(t, errors.As(err, &statusErr))
+	assert.NotEmpty(t, statusErr.ClientOrderId)
+	assert.Contains(t, statusErr.QueryErr.Error(), "network is unreachable")
+	assert.ErrorIs(t, statusErr.Unwrap(), syscall.ECONNRESET)
+}
+
+func TestNewOrderReconcileContextDone(t *testing.T) {
+	service := getMockDeliveryTradeService()
+	request := &pb.NewOrderRequest{Symbol: "BTCUSD_PERP", Side: pb.OrderSide_BUY, Type: pb.OrderType_MARKET, Quantity: 1}
+
+	queries := 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		if req.Method == http.MethodPost {
+			// The request is written, then the deadline expires while waiting for the response
+			<-req.Context().Done()
+			return nil, &url.Error{Op: "Post", URL: req.URL.String(), Err: req.Context().Err()}
+		}
+		queries++
+		assert.Nil(t, req.Context().Err())
+		data := `{"clientOrderId": "abc", "orderId": 1917641, "status": "NEW", "symbol": "BTCUSD_PERP"}`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	resp, err := service.NewOrder(ctx, request)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, queries)
+	assert.EqualValues(t, 1917641, resp.OrderId)
+}
+
+type failingSigner struct{}
+
+func (failingSigner) Sign(payload []byte) (string, error) {
+	return "", errors.New("no key")
+}
+
+func TestNewOrderLocalErrorNotReconciled(t *testing.T) {
+	request := &pb.NewOrderRequest{Symbol: "BTCUSD_PERP", Side: pb.OrderSide_BUY, Type: pb.OrderType_MARKET, Quantity: 1}
+
+	queries := 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		switch {
+		case req.Method != http.MethodPost:
+			queries++
+			resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"orderId": 1}`))}
+		case req.Context().Err() != nil:
+			err = &url.Error{Op: "Post", URL: req.URL.String(), Err: req.Context().Err()}
+		default:
+			err = &url.Error{Op: "Post", URL: req.URL.String(), Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+		}
+		return
+	}
+
+	// The context is done before the order is sent
+	service := getMockDeliveryTradeService()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := service.NewOrder(ctx, request)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The connection is refused, so the order is not written
+	_, err = service.NewOrder(context.Background(), request)
+	assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+
+	// The request cannot be signed
+	service = NewDeliveryTradeService(mocks.MockDomain, mocks.MockApiKey, failingSigner{}, true, &mocks.MockHTTPClient{})
+	_, err = service.NewOrder(context.Background(), request)
+	assert.EqualError(t, err, "no key")
+
+	assert.EqualValues(t, 0, queries)
+}
+
+func TestCancelOrderByClientId(t *testing.T) {
+	service := getMockDeliveryTradeService()
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.EqualValues(t, http.MethodDelete, req.Method)
+		assert.EqualValues(t, "/dapi/v1/order", req.URL.Path)
+		params := req.URL.Query()
+		mocks.CheckTimestampAndSignature(t, params)
+		assert.EqualValues(t, "BTCUSD_PERP", params.Get("symbol"))
+		assert.EqualValues(t, "abc", params.Get("origClientOrderId"))
+		data := `{"clientOrderId": "abc", "orderId": 1917641, "status": "CANCELED", "symbol": "BTCUSD_PERP"}`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+	resp, err := service.CancelOrderByClientId(context.Background(), "BTCUSD_PERP", "abc")
+	assert.Nil(t, err)
+	assert.EqualValues(t, "CANCELED", resp.Status.String())
+}
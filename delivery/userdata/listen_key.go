@@ -0,0 +1,85 @@
+package userdata
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/h9896/bingo/delivery"
+	"github.com/h9896/bingo/profile"
+	"github.com/h9896/bingo/rpc"
+)
+
+// ListenKeyService manages the listenKey of the user data stream, the requests only need the api key
+type ListenKeyService interface {
+	// Start a new user data stream, the active listenKey is returned and extended if there is one
+	CreateListenKey(ctx context.Context) (string, error)
+	// Extend the validity of the listenKey by 60 minutes
+	KeepaliveListenKey(ctx context.Context) error
+	// Close the user data stream
+	CloseListenKey(ctx context.Context) error
+}
+
+type ListenKey struct {
+	ListenKey string `json:"listenKey"`
+}
+
+type listenKeyService struct {
+	httpclient rpc.GenericHttpClient
+	domain     string
+	apiPrefix  string
+}
+
+func NewListenKeyService(domain, apikey string, useSSL bool, client rpc.HTTPClient, opts ...rpc.ClientOption) ListenKeyService {
+	return &listenKeyService{
+		httpclient: rpc.NewGenericHttpClient(apikey, useSSL, client, opts...),
+		domain:     domain,
+	}
+}
+
+// Create the service with the COIN-M futures endpoints of a profile
+func NewListenKeyServiceWithProfile(p profile.Profile, apikey string, client rpc.HTTPClient, opts ...rpc.ClientOption) ListenKeyService {
+	return &listenKeyService{
+		httpclient: rpc.NewGenericHttpClient(apikey, p.CoinM.UseSSL, client, opts...),
+		domain:     p.CoinM.RestDomain,
+		apiPrefix:  p.CoinM.APIPrefix,
+	}
+}
+
+// Create the service with the options, the api key is required and the signer is not used
+func NewListenKeyServiceWithOptions(opts ...delivery.Option) (ListenKeyService, error) {
+	cfg, err := delivery.NewPublicConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("%w: api key is empty, use WithAPIKey", delivery.ErrInvalidConfig)
+	}
+
+	return &listenKeyService{
+		httpclient: cfg.NewHttpClient(),
+		domain:     cfg.Domain,
+		apiPrefix:  cfg.APIPrefix,
+	}, nil
+}
+
+func (s *listenKeyService) CreateListenKey(ctx context.Context) (string, error) {
+	resp, err := rpc.DoJSON[ListenKey](ctx, s.httpclient, s.request("post"))
+	return resp.ListenKey, err
+}
+
+func (s *listenKeyService) KeepaliveListenKey(ctx context.Context) error {
+	_, err := rpc.DoJSON[ListenKey](ctx, s.httpclient, s.request("put"))
+	return err
+}
+
+func (s *listenKeyService) CloseListenKey(ctx context.Context) error {
+	_, err := rpc.DoJSON[struct{}](ctx, s.httpclient, s.request("delete"))
+	return err
+}
+
+func (s *listenKeyService) request(method string) *rpc.Request {
+	endpoint := delivery.Endpoint(s.domain, s.apiPrefix, delivery.EntryPointListenKey)
+
+	return s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod(method),
+		rpc.SetPrivate(), delivery.Weight(delivery.EntryPointListenKey))
+}
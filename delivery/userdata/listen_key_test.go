@@ -0,0 +1,257 @@
+package userdata
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/h9896/bingo/delivery"
+	"github.com/h9896/bingo/mocks"
+	"github.com/h9896/bingo/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenKeyService(t *testing.T) {
+	service := NewListenKeyService(mocks.MockDomain, mocks.MockApiKey, true, &mocks.MockHTTPClient{})
+
+	methods := []string{}
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		methods = append(methods, req.Method)
+		mocks.CheckHeader(t, req.Header)
+		assert.EqualValues(t, mocks.MockDomain, req.URL.Host)
+		assert.EqualValues(t, "/dapi/v1/listenKey", req.URL.Path)
+		// Only the api key is needed
+		assert.Empty(t, req.URL.Query().Get("signature"))
+		data := `{}`
+		if req.Method != http.MethodDelete {
+			data = `{"listenKey": "pqia91ma19a5s61cv6a81va65sdf19v8a65a1a5s61cv6a81va65sdf19v8a65a1"}`
+		}
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	key, err := service.CreateListenKey(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, "pqia91ma19a5s61cv6a81va65sdf19v8a65a1a5s61cv6a81va65sdf19v8a65a1", key)
+	assert.Nil(t, service.KeepaliveListenKey(context.Background()))
+	assert.Nil(t, service.CloseListenKey(context.Background()))
+	assert.EqualValues(t, []string{http.MethodPost, http.MethodPut, http.MethodDelete}, methods)
+}
+
+func TestNewListenKeyServiceWithOptions(t *testing.T) {
+	_, err := NewListenKeyServiceWithOptions(delivery.WithDomain(mocks.MockDomain, true))
+	assert.True(t, errors.Is(err, delivery.ErrInvalidConfig))
+
+	_, err = NewListenKeyServiceWithOptions(delivery.WithDomain(mocks.MockDomain, true), delivery.WithAPIKey(mocks.MockApiKey))
+	assert.Nil(t, err)
+}
+
+type mockListenKeyService struct {
+	mu           sync.Mutex
+	keys         []string
+	calls        []string
+	keepaliveErr error
+}
+
+func (m *mockListenKeyService) CreateListenKey(ctx context.Context) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, "create")
+	key := m.keys[0]
+	if len(m.keys) > 1 {
+		m.keys = m.keys[1:]
+	}
+	return key, nil
+}
+
+func (m *mockListenKeyService) KeepaliveListenKey(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, "keepalive")
+	err := m.keepaliveErr
+	m.keepaliveErr = nil
+	return err
+}
+
+func (m *mockListenKeyService) CloseListenKey(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, "close")
+	return nil
+}
+
+func (m *mockListenKeyService) getCalls() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string{}, m.calls...)
+}
+
+func TestUserDataStream(t *testing.T) {
+	service := &mockListenKeyService{keys: []string{"key1", "key2"}}
+	keys := make(chan string, 1)
+	stream := NewUserDataStream(service, WithKeepaliveInterval(10*time.Millisecond), WithKeyHandler(func(key string) {
+		keys <- key
+	}))
+	assert.Empty(t, stream.Key())
+
+	key, err := stream.Start(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, "key1", key)
+	_, err = stream.Start(context.Background())
+	assert.True(t, errors.Is(err, ErrStreamStarted))
+
+	assert.Eventually(t, func() bool {
+		return len(service.getCalls()) >= 3
+	}, time.Second, time.Millisecond)
+
+	// The listenKeyExpired event recreates the listenKey
+	stream.Expired()
+	select {
+	case key := <-keys:
+		assert.EqualValues(t, "key2", key)
+	case <-time.After(time.Second):
+		t.Fatal("the listenKey is not recreated")
+	}
+	assert.EqualValues(t, "key2", stream.Key())
+
+	assert.Nil(t, stream.Close())
+	assert.Nil(t, stream.Close())
+	assert.Empty(t, stream.Key())
+
+	calls := service.getCalls()
+	assert.EqualValues(t, []string{"create", "keepalive", "keepalive"}, calls[:3])
+	assert.EqualValues(t, "close", calls[len(calls)-1])
+}
+
+func TestUserDataStreamKeyNotExist(t *testing.T) {
+	service := &mockListenKeyService{
+		keys:         []string{"key1", "key2"},
+		keepaliveErr: &rpc.APIError{StatusCode: 400, Code: -1125, Message: "This listenKey does not exist."},
+	}
+	keys := make(chan string, 1)
+	stream := NewUserDataStream(service, WithKeepaliveInterval(10*time.Millisecond), WithKeyHandler(func(key string) {
+		keys <- key
+	}), WithErrHandler(func(err error) {
+		t.Errorf("unexpected error: %v", err)
+	}))
+
+	_, err := stream.Start(context.Background())
+	assert.Nil(t, err)
+
+	select {
+	case key := <-keys:
+		assert.EqualValues(t, "key2", key)
+	case <-time.After(time.Second):
+		t.Fatal("the listenKey is not recreated")
+	}
+	assert.Nil(t, stream.Close())
+	assert.EqualValues(t, []string{"create", "keepalive", "create"}, service.getCalls()[:3])
+}
+
+func TestUserDataStreamRetry(t *testing.T) {
+	service := &mockListenKeyService{keys: []string{"key1"}, keepaliveErr: errors.New("connection reset by peer")}
+	errs := make(chan error, 1)
+	stream := NewUserDataStream(service, WithKeepaliveInterval(10*time.Millisecond), WithRetryDelay(time.Millisecond),
+		WithErrHandler(func(err error) {
+			errs <- err
+		}))
+
+	_, err := stream.Start(context.Background())
+	assert.Nil(t, err)
+
+	select {
+	case err := <-errs:
+		assert.Contains(t, err.Error(), "connection reset by peer")
+	case <-time.After(time.Second):
+		t.Fatal("the error is not reported")
+	}
+
+	// The failed keepalive is retried without recreating the listenKey
+	assert.Eventually(t, func() bool {
+		return len(service.getCalls()) >= 3
+	}, time.Second, time.Millisecond)
+	assert.Nil(t, stream.Close())
+	assert.EqualValues(t, []string{"create", "keepalive", "keepalive"}, service.getCalls()[:3])
+}
+
+func TestUserDataStreamCloseFromKeyHandler(t *testing.T) {
+	service := &mockListenKeyService{keys: []string{"key1", "key2", "key3"}}
+	closed := make(chan error, 1)
+	var stream *UserDataStream
+	stream = NewUserDataStream(service, WithKeyHandler(func(key string) {
+		closed <- stream.Close()
+	}))
+
+	_, err := stream.Start(context.Background())
+	assert.Nil(t, err)
+
+	stream.Expired()
+	select {
+	case err := <-closed:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the stream is not closed by the key handler")
+	}
+	assert.Empty(t, stream.Key())
+
+	// A closed stream can be started again
+	key, err := stream.Start(context.Background())
+	assert.Nil(t, err)
+	assert.EqualValues(t, "key3", key)
+	assert.Nil(t, stream.Close())
+	assert.EqualValues(t, []string{"create", "create", "close", "create", "close"}, service.getCalls())
+}
+
+func TestUserDataStreamCloseFromErrHandler(t *testing.T) {
+	service := &mockListenKeyService{keys: []string{"key1"}, keepaliveErr: errors.New("connection reset by peer")}
+	closed := make(chan error, 1)
+	var stream *UserDataStream
+	stream = NewUserDataStream(service, WithKeepaliveInterval(10*time.Millisecond), WithErrHandler(func(err error) {
+		closed <- stream.Close()
+	}))
+
+	_, err := stream.Start(context.Background())
+	assert.Nil(t, err)
+
+	select {
+	case err := <-closed:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("the stream is not closed by the error handler")
+	}
+	assert.Empty(t, stream.Key())
+	assert.EqualValues(t, []string{"create", "keepalive", "close"}, service.getCalls())
+}
+
+func TestUserDataStreamNoHandlerAfterClose(t *testing.T) {
+	keys := []string{}
+	for i := 0; i < 100; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+	service := &mockListenKeyService{keys: keys}
+	var handled int32
+	stream := NewUserDataStream(service, WithKeyHandler(func(key string) {
+		atomic.AddInt32(&handled, 1)
+	}))
+
+	_, err := stream.Start(context.Background())
+	assert.Nil(t, err)
+	for i := 0; i < 20; i++ {
+		stream.Expired()
+		time.Sleep(time.Millisecond)
+	}
+	assert.Nil(t, stream.Close())
+
+	// The keys which are not delivered when it is closed are dropped
+	count := atomic.LoadInt32(&handled)
+	assert.Greater(t, count, int32(0))
+	time.Sleep(20 * time.Millisecond)
+	assert.EqualValues(t, count, atomic.LoadInt32(&handled))
+}
@@ -3,81 +3,74 @@ package userdata
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
 
-	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	pb "github.com/h9896/bingo-pkg-protobuf/services/delivery/v1"
 	"github.com/h9896/bingo/delivery"
+	"github.com/h9896/bingo/profile"
 	"github.com/h9896/bingo/rpc"
-	"google.golang.org/protobuf/encoding/protojson"
 )
 
 type deliveryUserDataService struct {
 	httpclient rpc.GenericHttpClient
 	domain     string
-	secret     string
-	m          *runtime.JSONPb
+	apiPrefix  string
+	signer     rpc.Signer
 }
 
-func NewDeliveryUserDataService(domain, apikey, secret string, useSSL bool, client rpc.HTTPClient) pb.DeliveryUserDataServiceServer {
+func NewDeliveryUserDataService(domain, apikey string, signer rpc.Signer, useSSL bool, client rpc.HTTPClient, opts ...rpc.ClientOption) pb.DeliveryUserDataServiceServer {
 	service := &deliveryUserDataService{
 		domain: domain,
-		secret: secret,
-		m: &runtime.JSONPb{
-			UnmarshalOptions: protojson.UnmarshalOptions{
-				DiscardUnknown: true,
-			},
-			MarshalOptions: protojson.MarshalOptions{
-				UseProtoNames: true,
-			},
-		},
+		signer: signer,
 	}
 	if client == nil {
-		service.httpclient = rpc.NewGenericHttpClient(apikey, useSSL, nil)
+		service.httpclient = rpc.NewGenericHttpClient(apikey, useSSL, nil, opts...)
 	} else {
-		service.httpclient = rpc.NewGenericHttpClient(apikey, useSSL, client)
+		service.httpclient = rpc.NewGenericHttpClient(apikey, useSSL, client, opts...)
 	}
 
 	return service
 }
 
-// Get user's position mode (Hedge Mode or One-way Mode ) on EVERY symbol
-func (s *deliveryUserDataService) GetPositionMode(ctx context.Context, request *pb.Empty) (*pb.GetPositionModeResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointPositionMode)
-
-	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
-		rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+// Create the service with the COIN-M futures endpoints of a profile
+func NewDeliveryUserDataServiceWithProfile(p profile.Profile, apikey string, signer rpc.Signer, client rpc.HTTPClient, opts ...rpc.ClientOption) pb.DeliveryUserDataServiceServer {
+	service := NewDeliveryUserDataService(p.CoinM.RestDomain, apikey, signer, p.CoinM.UseSSL, client, opts...).(*deliveryUserDataService)
+	service.apiPrefix = p.CoinM.APIPrefix
+	return service
+}
 
+// Create the service with the options, an invalid configuration is returned as an error
+func NewDeliveryUserDataServiceWithOptions(opts ...delivery.Option) (pb.DeliveryUserDataServiceServer, error) {
+	cfg, err := delivery.NewConfig(opts...)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	defer resp.Body.Close()
+	return &deliveryUserDataService{
+		httpclient: cfg.NewHttpClient(),
+		domain:     cfg.Domain,
+		apiPrefix:  cfg.APIPrefix,
+		signer:     cfg.Signer,
+	}, nil
+}
 
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+// Get the endpoint of an entry point with the API prefix of the service
+func (s *deliveryUserDataService) endpoint(entryPoint string) string {
+	return delivery.Endpoint(s.domain, s.apiPrefix, entryPoint)
+}
 
-	out := &pb.GetPositionModeResponse{}
+// Get user's position mode (Hedge Mode or One-way Mode ) on EVERY symbol
+func (s *deliveryUserDataService) GetPositionMode(ctx context.Context, request *pb.Empty) (*pb.GetPositionModeResponse, error) {
+	endpoint := s.endpoint(delivery.EntryPointPositionMode)
 
-	err = s.m.Unmarshal(respBody, out)
+	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
+		rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointPositionMode))
 
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+	return rpc.Do[*pb.GetPositionModeResponse](ctx, s.httpclient, req)
 }
 
 // Get order modification history
 func (s *deliveryUserDataService) GetOrderModifyHistory(ctx context.Context, request *pb.GetOrderModifyHistoryRequest) (*pb.GetOrderModifyHistoryResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointOrderAmendment)
+	endpoint := s.endpoint(delivery.EntryPointOrderAmendment)
 
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
@@ -109,43 +102,19 @@ func (s *deliveryUserDataService) GetOrderModifyHistory(ctx context.Context, req
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
 		rpc.SetParams(body...), rpc.SetPrivate(),
-		rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	defer resp.Body.Close()
+		rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointOrderAmendment))
 
-	respBody, err := ioutil.ReadAll(resp.Body)
+	list, err := rpc.DoList[*pb.OrderModifyHistory](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	out := &pb.GetOrderModifyHistoryResponse{}
-
-	listHistory := &[]*pb.OrderModifyHistory{}
-
-	err = s.m.Unmarshal(respBody, listHistory)
-
-	if err != nil {
-		return nil, err
-	}
-
-	out.OrderModifyHistory = *listHistory
-
-	return out, nil
+	return &pb.GetOrderModifyHistoryResponse{OrderModifyHistory: list}, nil
 }
 
 // Check an order's status
 func (s *deliveryUserDataService) QueryOrder(ctx context.Context, request *pb.QueryOrderRequest) (*pb.QueryOrderResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointOrder)
+	endpoint := s.endpoint(delivery.EntryPointOrder)
 
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
@@ -165,39 +134,14 @@ func (s *deliveryUserDataService) QueryOrder(ctx context.Context, request *pb.Qu
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
 		rpc.SetParams(body...), rpc.SetPrivate(),
-		rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	defer resp.Body.Close()
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.QueryOrderResponse{}
+		rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointOrder))
 
-	err = s.m.Unmarshal(respBody, out)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return out, nil
+	return rpc.Do[*pb.QueryOrderResponse](ctx, s.httpclient, req)
 }
 
 // Query Current Open Order
 func (s *deliveryUserDataService) QueryCurrentOpenOrder(ctx context.Context, request *pb.QueryCurrentOpenOrderRequest) (*pb.QueryCurrentOpenOrderResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointOpenOrder)
+	endpoint := s.endpoint(delivery.EntryPointOpenOrder)
 
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
@@ -217,40 +161,15 @@ func (s *deliveryUserDataService) QueryCurrentOpenOrder(ctx context.Context, req
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
 		rpc.SetParams(body...), rpc.SetPrivate(),
-		rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	defer resp.Body.Close()
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.QueryCurrentOpenOrderResponse{}
-
-	err = s.m.Unmarshal(respBody, out)
-
-	if err != nil {
-		return nil, err
-	}
+		rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointOpenOrder))
 
-	return out, nil
+	return rpc.Do[*pb.QueryCurrentOpenOrderResponse](ctx, s.httpclient, req)
 }
 
 // Get all open orders on a symbol.
 // Careful when accessing this with no symbol.
 func (s *deliveryUserDataService) CurrentAllOpenOrders(ctx context.Context, request *pb.CurrentAllOpenOrdersRequest) (*pb.CurrentAllOpenOrdersResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointOpenOrders)
+	endpoint := s.endpoint(delivery.EntryPointOpenOrders)
 
 	body := []*rpc.HttpParameter{}
 
@@ -268,43 +187,19 @@ func (s *deliveryUserDataService) CurrentAllOpenOrders(ctx context.Context, requ
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
 		rpc.SetParams(body...), rpc.SetPrivate(),
-		rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	defer resp.Body.Close()
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.CurrentAllOpenOrdersResponse{}
-
-	openOrders := &[]*pb.QueryCurrentOpenOrderResponse{}
-
-	err = s.m.Unmarshal(respBody, openOrders)
+		rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointOpenOrders))
 
+	list, err := rpc.DoList[*pb.QueryCurrentOpenOrderResponse](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	out.CurrentAllOpenOrders = *openOrders
-
-	return out, nil
+	return &pb.CurrentAllOpenOrdersResponse{CurrentAllOpenOrders: list}, nil
 }
 
 // Get all account orders; active, canceled, or filled.{
 func (s *deliveryUserDataService) AllOrders(ctx context.Context, request *pb.AllOrdersRequest) (*pb.AllOrdersResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointAllOrders)
+	endpoint := s.endpoint(delivery.EntryPointAllOrders)
 
 	body := []*rpc.HttpParameter{}
 
@@ -338,81 +233,34 @@ func (s *deliveryUserDataService) AllOrders(ctx context.Context, request *pb.All
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
 		rpc.SetParams(body...), rpc.SetPrivate(),
-		rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	defer resp.Body.Close()
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.AllOrdersResponse{}
-
-	allOrders := &[]*pb.QueryCurrentOpenOrderResponse{}
-
-	err = s.m.Unmarshal(respBody, allOrders)
+		rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointAllOrders))
 
+	list, err := rpc.DoList[*pb.QueryCurrentOpenOrderResponse](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	out.AllOrders = *allOrders
-
-	return out, nil
+	return &pb.AllOrdersResponse{AllOrders: list}, nil
 }
 
 // Futures Account Balance
 func (s *deliveryUserDataService) FuturesAccountBalance(ctx context.Context, request *pb.Empty) (*pb.FuturesAccountBalanceResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointBalance)
+	endpoint := s.endpoint(delivery.EntryPointBalance)
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
-		rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	defer resp.Body.Close()
+		rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointBalance))
 
-	respBody, err := ioutil.ReadAll(resp.Body)
+	list, err := rpc.DoList[*pb.Balance](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	out := &pb.FuturesAccountBalanceResponse{}
-
-	balances := []*pb.Balance{}
-
-	err = s.m.Unmarshal(respBody, &balances)
-
-	if err != nil {
-		return nil, err
-	}
-
-	out.FuturesAccountBalance = balances
-	return out, nil
+	return &pb.FuturesAccountBalanceResponse{FuturesAccountBalance: list}, nil
 }
 
 // Position Information
 func (s *deliveryUserDataService) PositionInformation(ctx context.Context, request *pb.PositionInformationRequest) (*pb.PositionInformationResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointPositionRisk)
+	endpoint := s.endpoint(delivery.EntryPointPositionRisk)
 
 	body := []*rpc.HttpParameter{}
 
@@ -430,43 +278,19 @@ func (s *deliveryUserDataService) PositionInformation(ctx context.Context, reque
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
 		rpc.SetParams(body...), rpc.SetPrivate(),
-		rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	defer resp.Body.Close()
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.PositionInformationResponse{}
-
-	positions := []*pb.PositionString{}
-
-	err = s.m.Unmarshal(respBody, &positions)
+		rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointPositionRisk))
 
+	list, err := rpc.DoList[*pb.PositionString](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	out.Positions = positions
-
-	return out, nil
+	return &pb.PositionInformationResponse{Positions: list}, nil
 }
 
 // Get Position Margin Change History
 func (s *deliveryUserDataService) GetPositionMarginChangeHistory(ctx context.Context, request *pb.GetPositionMarginChangeHistoryRequest) (*pb.GetPositionMarginChangeHistoryResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s/%s", s.domain, delivery.EntryPointPositionMargin, delivery.History)
+	endpoint := s.endpoint(delivery.EntryPointPositionMarginHistory)
 	body := []*rpc.HttpParameter{
 		{Key: "symbol", Val: request.GetSymbol()},
 	}
@@ -492,75 +316,29 @@ func (s *deliveryUserDataService) GetPositionMarginChangeHistory(ctx context.Con
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("GET"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointPositionMarginHistory))
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
+	list, err := rpc.DoList[*pb.PositionMargin](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	out := &pb.GetPositionMarginChangeHistoryResponse{}
-
-	listPositionMargin := &[]*pb.PositionMargin{}
-
-	err = s.m.Unmarshal(respBody, listPositionMargin)
-
-	out.PositionMargins = *listPositionMargin
-
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+	return &pb.GetPositionMarginChangeHistoryResponse{PositionMargins: list}, nil
 }
 
 // Get current account information.
 func (s *deliveryUserDataService) AccountInformation(ctx context.Context, request *pb.Empty) (*pb.AccountInformationResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointAccount)
+	endpoint := s.endpoint(delivery.EntryPointAccount)
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("get"),
-		rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
-
-	if err != nil {
-		return nil, err
-	}
+		rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointAccount))
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	defer resp.Body.Close()
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.AccountInformationResponse{}
-
-	err = s.m.Unmarshal(respBody, out)
-
-	if err != nil {
-		return nil, err
-	}
-
-	return out, nil
+	return rpc.Do[*pb.AccountInformationResponse](ctx, s.httpclient, req)
 }
 
 // Get trades for a specific account and symbol.
 func (s *deliveryUserDataService) AccountTradeList(ctx context.Context, request *pb.AccountTradeListRequest) (*pb.AccountTradeListResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointUserTrades)
+	endpoint := s.endpoint(delivery.EntryPointUserTrades)
 	body := []*rpc.HttpParameter{}
 
 	if request.GetRecvWindow() != 0 {
@@ -592,40 +370,19 @@ func (s *deliveryUserDataService) AccountTradeList(ctx context.Context, request
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("GET"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointUserTrades))
 
+	list, err := rpc.DoList[*pb.AccountTrade](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.AccountTradeListResponse{}
-
-	listAccountTrade := &[]*pb.AccountTrade{}
-
-	err = s.m.Unmarshal(respBody, listAccountTrade)
-
-	out.AccountTrades = *listAccountTrade
-
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+	return &pb.AccountTradeListResponse{AccountTrades: list}, nil
 }
 
 // Get Income History
 func (s *deliveryUserDataService) GetIncomeHistory(ctx context.Context, request *pb.GetIncomeHistoryRequest) (*pb.GetIncomeHistoryResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointIncome)
+	endpoint := s.endpoint(delivery.EntryPointIncome)
 	body := []*rpc.HttpParameter{}
 
 	if request.GetRecvWindow() != 0 {
@@ -653,40 +410,19 @@ func (s *deliveryUserDataService) GetIncomeHistory(ctx context.Context, request
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("GET"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointIncome))
 
+	list, err := rpc.DoList[*pb.IncomeHistory](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.GetIncomeHistoryResponse{}
-
-	incomes := &[]*pb.IncomeHistory{}
-
-	err = s.m.Unmarshal(respBody, incomes)
-
-	out.Incomes = *incomes
-
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+	return &pb.GetIncomeHistoryResponse{Incomes: list}, nil
 }
 
 // Get the pair's default notional bracket list.
 func (s *deliveryUserDataService) NotionalBracketForSymbol(ctx context.Context, request *pb.NotionalBracketForSymbolRequest) (*pb.NotionalBracketForSymbolResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointLeverageBracket)
+	endpoint := s.endpoint(delivery.EntryPointLeverageBracket)
 	body := []*rpc.HttpParameter{}
 
 	if request.GetRecvWindow() != 0 {
@@ -698,40 +434,19 @@ func (s *deliveryUserDataService) NotionalBracketForSymbol(ctx context.Context,
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("GET"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointLeverageBracket))
 
+	list, err := rpc.DoList[*pb.NotionalBracketForSymbol](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.NotionalBracketForSymbolResponse{}
-
-	brackets := []*pb.NotionalBracketForSymbol{}
-
-	err = s.m.Unmarshal(respBody, &brackets)
-
-	if err != nil {
-		return nil, err
-	}
-	out.Brackets = brackets
-
-	return out, nil
+	return &pb.NotionalBracketForSymbolResponse{Brackets: list}, nil
 }
 
 // Get the symbol's notional bracket list.
 func (s *deliveryUserDataService) NotionalBracketForPair(ctx context.Context, request *pb.NotionalBracketForPairRequest) (*pb.NotionalBracketForPairResponse, error) {
-	endpoint := fmt.Sprintf("%s/%s", s.domain, delivery.EntryPointLeverageBracketV2)
+	endpoint := s.endpoint(delivery.EntryPointLeverageBracketV2)
 	body := []*rpc.HttpParameter{}
 
 	if request.GetRecvWindow() != 0 {
@@ -743,33 +458,12 @@ func (s *deliveryUserDataService) NotionalBracketForPair(ctx context.Context, re
 	}
 
 	req := s.httpclient.GetHttpRequest(rpc.SetEndpoint(endpoint), rpc.SetMethod("GET"),
-		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSignature(s.secret))
-
-	resp, err := s.httpclient.ExecuteHttpOperation(ctx, req)
+		rpc.SetParams(body...), rpc.SetPrivate(), rpc.SetTimestamp(), rpc.SetSigner(s.signer), delivery.Weight(delivery.EntryPointLeverageBracketV2))
 
+	list, err := rpc.DoList[*pb.NotionalBracketForPair](ctx, s.httpclient, req)
 	if err != nil {
 		return nil, err
 	}
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("Something wrong")
-	}
-
-	respBody, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	out := &pb.NotionalBracketForPairResponse{}
-
-	brackets := &[]*pb.NotionalBracketForPair{}
-
-	err = s.m.Unmarshal(respBody, brackets)
-
-	out.Brackets = *brackets
-
-	if err != nil {
-		return nil, err
-	}
-	return out, nil
+	return &pb.NotionalBracketForPairResponse{Brackets: list}, nil
 }
@@ -12,12 +12,13 @@ import (
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	pb "github.com/h9896/bingo-pkg-protobuf/services/delivery/v1"
 	"github.com/h9896/bingo/mocks"
+	"github.com/h9896/bingo/rpc"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
 func getMockDeliveryUserDataService() pb.DeliveryUserDataServiceServer {
-	return NewDeliveryUserDataService(mocks.MockDomain, mocks.MockApiKey, mocks.MockSecret, true, &mocks.MockHTTPClient{})
+	return NewDeliveryUserDataService(mocks.MockDomain, mocks.MockApiKey, rpc.NewHMACSigner(mocks.MockSecret), true, &mocks.MockHTTPClient{})
 }
 
 var m = &runtime.JSONPb{
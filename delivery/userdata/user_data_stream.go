@@ -0,0 +1,306 @@
+package userdata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/h9896/bingo/rpc"
+)
+
+const (
+	// The listenKey expires 60 minutes after it is created or extended
+	DefaultKeepaliveInterval = 30 * time.Minute
+	// The delay before a failed keepalive or creation is retried
+	DefaultRetryDelay = time.Minute
+
+	// This listenKey does not exist
+	codeListenKeyNotExist = -1125
+
+	// Time given to every request of the listenKey
+	listenKeyTimeout = 10 * time.Second
+)
+
+var ErrStreamStarted = errors.New("user data stream is already started")
+
+// UserDataStream keeps the listenKey of a user data stream alive,
+// the listenKey is recreated when it expires and closed on Close.
+// A closed stream can be started again.
+type UserDataStream struct {
+	service    ListenKeyService
+	interval   time.Duration
+	retryDelay time.Duration
+	keyHandler func(key string)
+	errHandler func(err error)
+
+	// Serializes Start and Close
+	lifecycle sync.Mutex
+
+	mu      sync.Mutex
+	key     string
+	started bool
+	expired chan struct{}
+	session *session
+}
+
+// The goroutines of a started stream
+type session struct {
+	quit chan struct{}
+	// Closed when the keepalive goroutine exits
+	done chan struct{}
+	// Closed when the notify goroutine exits
+	notifyDone chan struct{}
+	// Set while the notify goroutine runs a handler, Close does not wait for it then
+	handling bool
+}
+
+type StreamOption func(s *UserDataStream)
+
+// Extend the listenKey at the interval, DefaultKeepaliveInterval is used by default
+func WithKeepaliveInterval(d time.Duration) StreamOption {
+	return func(s *UserDataStream) {
+		s.interval = d
+	}
+}
+
+// Retry a failed keepalive or creation after the delay, DefaultRetryDelay is used by default
+func WithRetryDelay(d time.Duration) StreamOption {
+	return func(s *UserDataStream) {
+		s.retryDelay = d
+	}
+}
+
+// Receive the new listenKey after it is recreated, the streams of the old one must be reconnected with it.
+// The handler is called on the notify goroutine, so it may call Close, and it only gets the latest key when it is slow.
+func WithKeyHandler(handler func(key string)) StreamOption {
+	return func(s *UserDataStream) {
+		s.keyHandler = handler
+	}
+}
+
+// Receive the errors of the keepalive and creation requests made in the background.
+// The handler is called on the notify goroutine like the key handler, so it may call Close.
+func WithErrHandler(handler func(err error)) StreamOption {
+	return func(s *UserDataStream) {
+		s.errHandler = handler
+	}
+}
+
+func NewUserDataStream(service ListenKeyService, opts ...StreamOption) *UserDataStream {
+	s := &UserDataStream{
+		service:    service,
+		interval:   DefaultKeepaliveInterval,
+		retryDelay: DefaultRetryDelay,
+		keyHandler: func(key string) {},
+		errHandler: func(err error) {},
+		expired:    make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Create the listenKey and keep it alive in the background until Close is called
+func (s *UserDataStream) Start(ctx context.Context) (string, error) {
+	s.lifecycle.Lock()
+	defer s.lifecycle.Unlock()
+
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+	if started {
+		return "", ErrStreamStarted
+	}
+
+	key, err := s.service.CreateListenKey(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	// An expiry signalled before the stream is started is stale
+	select {
+	case <-s.expired:
+	default:
+	}
+
+	sess := &session{quit: make(chan struct{}), done: make(chan struct{}), notifyDone: make(chan struct{})}
+	keys, errs := make(chan string, 1), make(chan error)
+	s.mu.Lock()
+	s.started = true
+	s.key = key
+	s.session = sess
+	s.mu.Unlock()
+
+	go s.run(sess, keys, errs)
+	go s.notify(sess, keys, errs)
+	return key, nil
+}
+
+// Get the current listenKey, it is empty before Start and after Close
+func (s *UserDataStream) Key() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.key
+}
+
+// Recreate the listenKey, call it on the listenKeyExpired event
+func (s *UserDataStream) Expired() {
+	select {
+	case s.expired <- struct{}{}:
+	default:
+	}
+}
+
+// Stop the keepalive and close the listenKey, no handler is called after it returns.
+// It can be called from the handlers, it does not wait for a handler which is running.
+func (s *UserDataStream) Close() error {
+	s.lifecycle.Lock()
+	defer s.lifecycle.Unlock()
+
+	s.mu.Lock()
+	started, sess := s.started, s.session
+	if started {
+		s.started = false
+		close(sess.quit)
+	}
+	s.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	<-sess.done
+	s.mu.Lock()
+	handling := sess.handling
+	s.mu.Unlock()
+	if !handling {
+		<-sess.notifyDone
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), listenKeyTimeout)
+	defer cancel()
+	err := s.service.CloseListenKey(ctx)
+
+	s.mu.Lock()
+	s.key = ""
+	s.mu.Unlock()
+	return err
+}
+
+func (s *UserDataStream) run(sess *session, keys chan string, errs chan<- error) {
+	defer close(sess.done)
+
+	timer := time.NewTimer(s.interval)
+	defer timer.Stop()
+
+	recreate := false
+	for {
+		select {
+		case <-sess.quit:
+			return
+		case <-s.expired:
+			recreate = true
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-timer.C:
+		}
+
+		var err error
+		if !recreate {
+			err = s.keepalive()
+			// The listenKey which does not exist any more is recreated at once
+			var apiErr *rpc.APIError
+			if errors.As(err, &apiErr) && apiErr.Code == codeListenKeyNotExist {
+				recreate = true
+			}
+		}
+		if recreate {
+			err = s.recreate(keys)
+		}
+
+		if err != nil {
+			select {
+			case errs <- err:
+			case <-sess.quit:
+				return
+			}
+			timer.Reset(s.retryDelay)
+			continue
+		}
+		recreate = false
+		timer.Reset(s.interval)
+	}
+}
+
+// Pass the recreated listenKeys and the errors to the handlers outside the keepalive goroutine
+func (s *UserDataStream) notify(sess *session, keys <-chan string, errs <-chan error) {
+	defer close(sess.notifyDone)
+	for {
+		var handler func()
+		select {
+		case <-sess.quit:
+			return
+		case key := <-keys:
+			handler = func() { s.keyHandler(key) }
+		case err := <-errs:
+			handler = func() { s.errHandler(err) }
+		}
+		if !s.handle(sess, handler) {
+			return
+		}
+	}
+}
+
+// Run a handler unless the stream is closed, false is returned when it is closed
+func (s *UserDataStream) handle(sess *session, handler func()) bool {
+	s.mu.Lock()
+	select {
+	case <-sess.quit:
+		s.mu.Unlock()
+		return false
+	default:
+	}
+	sess.handling = true
+	s.mu.Unlock()
+
+	handler()
+
+	s.mu.Lock()
+	sess.handling = false
+	s.mu.Unlock()
+	return true
+}
+
+func (s *UserDataStream) keepalive() error {
+	ctx, cancel := context.WithTimeout(context.Background(), listenKeyTimeout)
+	defer cancel()
+	return s.service.KeepaliveListenKey(ctx)
+}
+
+func (s *UserDataStream) recreate(keys chan string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), listenKeyTimeout)
+	defer cancel()
+	key, err := s.service.CreateListenKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	changed := key != s.key
+	s.key = key
+	s.mu.Unlock()
+
+	if changed {
+		// A key which is not delivered yet is replaced by the latest one
+		select {
+		case <-keys:
+		default:
+		}
+		keys <- key
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/h9896/bingo/decimal"
+)
+
+// ValidationError is an order rejected locally by a filter of exchangeInfo
+type ValidationError struct {
+	Symbol string
+	Filter string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("<ValidationError> symbol=%s, filter=%s, reason=%s", e.Symbol, e.Filter, e.Reason)
+}
+
+// Order to validate, the zero values are not checked
+type OrderCheck struct {
+	Symbol    string
+	Type      string
+	Quantity  decimal.Decimal
+	Price     decimal.Decimal
+	StopPrice decimal.Decimal
+
+	// Mark price of the symbol for the PERCENT_PRICE filter
+	MarkPrice decimal.Decimal
+	// Number of the open orders of the symbol for the MAX_NUM_ORDERS filter
+	OpenOrders int64
+}
+
+func isMarket(orderType string) bool {
+	switch orderType {
+	case "MARKET", "STOP_MARKET", "TAKE_PROFIT_MARKET", "TRAILING_STOP_MARKET":
+		return true
+	}
+	return false
+}
+
+// Check an order against the filters of the symbol
+func (s SymbolInfo) Validate(order OrderCheck) error {
+	reject := func(filter, format string, a ...interface{}) error {
+		return &ValidationError{Symbol: s.Symbol, Filter: filter, Reason: fmt.Sprintf(format, a...)}
+	}
+
+	if f, ok := s.Filter(FilterPrice); ok {
+		prices := []struct {
+			name  string
+			price decimal.Decimal
+		}{{"price", order.Price}, {"stopPrice", order.StopPrice}}
+		for _, p := range prices {
+			name, price := p.name, p.price
+			if price.IsZero() {
+				continue
+			}
+			if f.MinPrice.Sign() > 0 && price.Cmp(f.MinPrice) < 0 {
+				return reject(FilterPrice, "%s %s is less than the minimum %s", name, price, f.MinPrice)
+			}
+			if f.MaxPrice.Sign() > 0 && price.Cmp(f.MaxPrice) > 0 {
+				return reject(FilterPrice, "%s %s is greater than the maximum %s", name, price, f.MaxPrice)
+			}
+			if !price.Sub(f.MinPrice).IsMultipleOf(f.TickSize) {
+				return reject(FilterPrice, "%s %s is not a multiple of the tick size %s", name, price, f.TickSize)
+			}
+		}
+	}
+
+	lotSize := FilterLotSize
+	if isMarket(order.Type) {
+		lotSize = FilterMarketLotSize
+	}
+	if f, ok := s.Filter(lotSize); ok && !order.Quantity.IsZero() {
+		if order.Quantity.Cmp(f.MinQty) < 0 {
+			return reject(lotSize, "quantity %s is less than the minimum %s", order.Quantity, f.MinQty)
+		}
+		if f.MaxQty.Sign() > 0 && order.Quantity.Cmp(f.MaxQty) > 0 {
+			return reject(lotSize, "quantity %s is greater than the maximum %s", order.Quantity, f.MaxQty)
+		}
+		if !order.Quantity.Sub(f.MinQty).IsMultipleOf(f.StepSize) {
+			return reject(lotSize, "quantity %s is not a multiple of the step size %s", order.Quantity, f.StepSize)
+		}
+	}
+
+	if f, ok := s.Filter(FilterMaxNumOrders); ok && f.Limit > 0 && order.OpenOrders >= f.Limit {
+		return reject(FilterMaxNumOrders, "%d open orders reach the limit %d", order.OpenOrders, f.Limit)
+	}
+
+	if f, ok := s.Filter(FilterPercentPrice); ok && !order.MarkPrice.IsZero() && !order.Price.IsZero() {
+		if up := order.MarkPrice.Mul(f.MultiplierUp); f.MultiplierUp.Sign() > 0 && order.Price.Cmp(up) > 0 {
+			return reject(FilterPercentPrice, "price %s is greater than %s times the mark price %s", order.Price, f.MultiplierUp, order.MarkPrice)
+		}
+		if down := order.MarkPrice.Mul(f.MultiplierDown); order.Price.Cmp(down) < 0 {
+			return reject(FilterPercentPrice, "price %s is less than %s times the mark price %s", order.Price, f.MultiplierDown, order.MarkPrice)
+		}
+	}
+
+	return nil
+}
+
+// An unknown symbol loads exchangeInfo again when it is older than this, e.g. after the symbol is listed
+const unknownSymbolReload = time.Minute
+
+// Rules caches the symbols of exchangeInfo, they are loaded on the first use
+// and loaded again when an unknown symbol is asked for
+type Rules struct {
+	load func(ctx context.Context) (*ExchangeInfo, error)
+
+	mu       sync.Mutex
+	symbols  map[string]SymbolInfo
+	loadedAt time.Time
+}
+
+func NewRules(load func(ctx context.Context) (*ExchangeInfo, error)) *Rules {
+	return &Rules{load: load}
+}
+
+// Load exchangeInfo again, e.g. after a symbol is listed or its filters are changed
+func (r *Rules) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reload(ctx)
+}
+
+func (r *Rules) reload(ctx context.Context) error {
+	info, err := r.load(ctx)
+	if err != nil {
+		return fmt.Errorf("load exchangeInfo: %w", err)
+	}
+
+	symbols := make(map[string]SymbolInfo, len(info.Symbols))
+	for _, s := range info.Symbols {
+		symbols[s.Symbol] = s
+	}
+	r.symbols = symbols
+	r.loadedAt = time.Now()
+	return nil
+}
+
+// Get the information of a symbol, exchangeInfo is loaded if it has not been
+func (r *Rules) Symbol(ctx context.Context, symbol string) (SymbolInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.symbols == nil {
+		if err := r.reload(ctx); err != nil {
+			return SymbolInfo{}, err
+		}
+	}
+
+	s, ok := r.symbols[symbol]
+	if !ok && time.Since(r.loadedAt) >= unknownSymbolReload {
+		if err := r.reload(ctx); err != nil {
+			return SymbolInfo{}, err
+		}
+		s, ok = r.symbols[symbol]
+	}
+	if !ok {
+		return SymbolInfo{}, &ValidationError{Symbol: symbol, Reason: "unknown symbol"}
+	}
+	return s, nil
+}
+
+// Check an order against the filters of its symbol
+func (r *Rules) Validate(ctx context.Context, order OrderCheck) error {
+	s, err := r.Symbol(ctx, order.Symbol)
+	if err != nil {
+		return err
+	}
+	return s.Validate(order)
+}
@@ -0,0 +1,139 @@
+package delivery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/h9896/bingo/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const exchangeInfoData = `{
+	"exchangeFilters": [],
+	"rateLimits": [
+		{"interval": "MINUTE", "intervalNum": 1, "limit": 6000, "rateLimitType": "REQUEST_WEIGHT"},
+		{"interval": "MINUTE", "intervalNum": 1, "limit": 6000, "rateLimitType": "ORDERS"}
+	],
+	"serverTime": 1565613908500,
+	"symbols": [
+		{
+			"filters": [
+				{"filterType": "PRICE_FILTER", "maxPrice": "100000", "minPrice": "0.1", "tickSize": "0.1"},
+				{"filterType": "LOT_SIZE", "maxQty": "100000", "minQty": "1", "stepSize": "1"},
+				{"filterType": "MARKET_LOT_SIZE", "maxQty": "100", "minQty": "1", "stepSize": "1"},
+				{"filterType": "MAX_NUM_ORDERS", "limit": 200},
+				{"filterType": "PERCENT_PRICE", "multiplierUp": "1.0500", "multiplierDown": "0.9500", "multiplierDecimal": 4}
+			],
+			"OrderType": ["LIMIT", "MARKET", "STOP", "TAKE_PROFIT", "TRAILING_STOP_MARKET"],
+			"timeInForce": ["GTC", "IOC", "FOK", "GTX"],
+			"liquidationFee": "0.010000",
+			"marketTakeBound": "0.30",
+			"symbol": "BTCUSD_200925",
+			"pair": "BTCUSD",
+			"contractType": "CURRENT_QUARTER",
+			"deliveryDate": 1601020800000,
+			"onboardDate": 1590739200000,
+			"contractStatus": "TRADING",
+			"contractSize": 100,
+			"quoteAsset": "USD",
+			"baseAsset": "BTC",
+			"marginAsset": "BTC",
+			"pricePrecision": 1,
+			"quantityPrecision": 0,
+			"baseAssetPrecision": 8,
+			"quotePrecision": 8,
+			"equalQtyPrecision": 4,
+			"triggerProtect": "0.0500",
+			"maintMarginPercent": "2.5000",
+			"requiredMarginPercent": "5.0000",
+			"underlyingType": "COIN",
+			"underlyingSubType": []
+		}
+	],
+	"timezone": "UTC"
+}`
+
+func TestExchangeInfo(t *testing.T) {
+	info := &ExchangeInfo{}
+	assert.Nil(t, json.Unmarshal([]byte(exchangeInfoData), info))
+	assert.EqualValues(t, "UTC", info.Timezone)
+	assert.Len(t, info.RateLimits, 2)
+	assert.Len(t, info.Symbols, 1)
+
+	symbol := info.Symbols[0]
+	assert.EqualValues(t, "BTCUSD_200925", symbol.Symbol)
+	assert.EqualValues(t, 100, symbol.ContractSize)
+	assert.Contains(t, symbol.OrderTypes, "TRAILING_STOP_MARKET")
+
+	f, ok := symbol.Filter(FilterMaxNumOrders)
+	assert.True(t, ok)
+	assert.EqualValues(t, 200, f.Limit)
+	assert.EqualValues(t, "0.1", symbol.Precision().TickSize.String())
+	assert.EqualValues(t, "1", symbol.Precision().StepSize.String())
+}
+
+func TestSymbolInfoValidate(t *testing.T) {
+	info := &ExchangeInfo{}
+	assert.Nil(t, json.Unmarshal([]byte(exchangeInfoData), info))
+	symbol := info.Symbols[0]
+
+	valid := OrderCheck{Symbol: symbol.Symbol, Type: "LIMIT", Quantity: decimal.MustParse("10"), Price: decimal.MustParse("9300.5")}
+	assert.Nil(t, symbol.Validate(valid))
+
+	for filter, order := range map[string]OrderCheck{
+		FilterPrice:         {Type: "LIMIT", Quantity: decimal.MustParse("10"), Price: decimal.MustParse("9300.55")},
+		FilterLotSize:       {Type: "LIMIT", Quantity: decimal.MustParse("0.5"), Price: decimal.MustParse("9300.5")},
+		FilterMarketLotSize: {Type: "MARKET", Quantity: decimal.MustParse("101")},
+		FilterMaxNumOrders:  {Type: "LIMIT", Quantity: decimal.MustParse("1"), Price: decimal.MustParse("9300.5"), OpenOrders: 200},
+		FilterPercentPrice:  {Type: "LIMIT", Quantity: decimal.MustParse("1"), Price: decimal.MustParse("9800"), MarkPrice: decimal.MustParse("9300")},
+	} {
+		order.Symbol = symbol.Symbol
+		err := symbol.Validate(order)
+		var validationErr *ValidationError
+		assert.True(t, errors.As(err, &validationErr), filter)
+		assert.EqualValues(t, filter, validationErr.Filter)
+	}
+
+	// A stop price is checked by the PRICE_FILTER
+	err := symbol.Validate(OrderCheck{Symbol: symbol.Symbol, Type: "STOP_MARKET", StopPrice: decimal.MustParse("0.05")})
+	assert.Contains(t, err.Error(), "stopPrice 0.05 is less than the minimum 0.1")
+}
+
+func TestRules(t *testing.T) {
+	loads := 0
+	rules := NewRules(func(ctx context.Context) (*ExchangeInfo, error) {
+		loads++
+		info := &ExchangeInfo{}
+		err := json.Unmarshal([]byte(exchangeInfoData), info)
+		return info, err
+	})
+
+	order := OrderCheck{Symbol: "BTCUSD_200925", Type: "LIMIT", Quantity: decimal.MustParse("1"), Price: decimal.MustParse("9300")}
+	assert.Nil(t, rules.Validate(context.Background(), order))
+	assert.Nil(t, rules.Validate(context.Background(), order))
+	assert.EqualValues(t, 1, loads)
+
+	order.Symbol = "ETHUSD_200925"
+	err := rules.Validate(context.Background(), order)
+	assert.Contains(t, err.Error(), "unknown symbol")
+
+	assert.Nil(t, rules.Reload(context.Background()))
+	assert.EqualValues(t, 2, loads)
+
+	// An unknown symbol loads exchangeInfo again once it is old enough
+	rules.loadedAt = time.Now().Add(-unknownSymbolReload)
+	_, err = rules.Symbol(context.Background(), "ETHUSD_200925")
+	assert.Contains(t, err.Error(), "unknown symbol")
+	_, err = rules.Symbol(context.Background(), "ETHUSD_200925")
+	assert.Contains(t, err.Error(), "unknown symbol")
+	assert.EqualValues(t, 3, loads)
+
+	failing := NewRules(func(ctx context.Context) (*ExchangeInfo, error) {
+		return nil, errors.New("connection refused")
+	})
+	_, err = failing.Symbol(context.Background(), "BTCUSD_200925")
+	assert.Contains(t, err.Error(), "load exchangeInfo: connection refused")
+}
@@ -0,0 +1,135 @@
+package delivery
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/h9896/bingo/rpc"
+)
+
+type weightFunc func(params url.Values) int64
+
+func fixed(weight int64) weightFunc {
+	return func(params url.Values) int64 {
+		return weight
+	}
+}
+
+// The weight is higher when the request is not limited to a symbol
+func bySymbol(symbol, others int64) weightFunc {
+	return func(params url.Values) int64 {
+		if params.Get("symbol") != "" {
+			return symbol
+		}
+		return others
+	}
+}
+
+func byDepthLimit(params url.Values) int64 {
+	limit, err := strconv.Atoi(params.Get("limit"))
+	if err != nil {
+		// The default limit is 500
+		return 10
+	}
+	switch {
+	case limit <= 50:
+		return 2
+	case limit <= 100:
+		return 5
+	case limit <= 500:
+		return 10
+	default:
+		return 20
+	}
+}
+
+func byKlineLimit(params url.Values) int64 {
+	limit, err := strconv.Atoi(params.Get("limit"))
+	if err != nil {
+		// The default limit is 500
+		return 5
+	}
+	switch {
+	case limit < 100:
+		return 1
+	case limit < 500:
+		return 2
+	case limit <= 1000:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// The request weight of the endpoints, keyed by the http method and the entry point
+var weights = map[string]weightFunc{
+	http.MethodGet + EntryPointPositionMode:          fixed(30),
+	http.MethodPost + EntryPointPositionMode:         fixed(1),
+	http.MethodGet + EntryPointOrder:                 fixed(1),
+	http.MethodPost + EntryPointOrder:                fixed(1),
+	http.MethodPut + EntryPointOrder:                 fixed(1),
+	http.MethodDelete + EntryPointOrder:              fixed(1),
+	http.MethodPost + EntryPointMultipleOrders:       fixed(5),
+	http.MethodPut + EntryPointMultipleOrders:        fixed(5),
+	http.MethodDelete + EntryPointMultipleOrders:     fixed(1),
+	http.MethodDelete + EntryPointAllOpenOrders:      fixed(1),
+	http.MethodPost + EntryPointCountdownCancelAll:   fixed(10),
+	http.MethodPost + EntryPointLeverage:             fixed(1),
+	http.MethodPost + EntryPointMarginType:           fixed(1),
+	http.MethodPost + EntryPointPositionMargin:       fixed(1),
+	http.MethodGet + EntryPointPositionMarginHistory: fixed(1),
+	http.MethodGet + EntryPointOrderAmendment:        fixed(1),
+	http.MethodGet + EntryPointOpenOrder:             fixed(1),
+	http.MethodGet + EntryPointOpenOrders:            bySymbol(1, 40),
+	http.MethodGet + EntryPointAllOrders:             bySymbol(20, 40),
+	http.MethodGet + EntryPointBalance:               fixed(1),
+	http.MethodGet + EntryPointPositionRisk:          fixed(1),
+	http.MethodGet + EntryPointAccount:               fixed(5),
+	http.MethodGet + EntryPointUserTrades:            bySymbol(20, 40),
+	http.MethodGet + EntryPointIncome:                fixed(20),
+	http.MethodGet + EntryPointLeverageBracket:       fixed(1),
+	http.MethodGet + EntryPointLeverageBracketV2:     fixed(1),
+	http.MethodGet + EntryPointServerTime:            fixed(1),
+	http.MethodGet + EntryPointOrderBook:             byDepthLimit,
+	http.MethodGet + EntryPointExchangeInfo:          fixed(1),
+	http.MethodGet + EntryPointPing:                  fixed(1),
+	http.MethodGet + EntryPointRecentTrades:          fixed(5),
+	http.MethodGet + EntryPointHistoricalTrades:      fixed(20),
+	http.MethodGet + EntryPointAggTrades:             fixed(20),
+	http.MethodGet + EntryPointPremiumIndex:          fixed(10),
+	http.MethodGet + EntryPointFundingRate:           fixed(1),
+	http.MethodGet + EntryPointKlines:                byKlineLimit,
+	http.MethodGet + EntryPointContinuousKlines:      byKlineLimit,
+	http.MethodGet + EntryPointIndexPriceKlines:      byKlineLimit,
+	http.MethodGet + EntryPointMarkPriceKlines:       byKlineLimit,
+	http.MethodGet + EntryPointPremiumIndexKlines:    byKlineLimit,
+	http.MethodGet + EntryPointTicker24hr:            bySymbol(1, 40),
+	http.MethodGet + EntryPointTickerPrice:           bySymbol(1, 2),
+	http.MethodGet + EntryPointBookTicker:            bySymbol(2, 5),
+	http.MethodGet + EntryPointOpenInterest:          fixed(1),
+	http.MethodPost + EntryPointListenKey:            fixed(1),
+	http.MethodPut + EntryPointListenKey:             fixed(1),
+	http.MethodDelete + EntryPointListenKey:          fixed(1),
+	http.MethodGet + EntryPointOpenInterestHist:      fixed(1),
+	http.MethodGet + EntryPointTopLongShortAccount:   fixed(1),
+	http.MethodGet + EntryPointTopLongShortPosition:  fixed(1),
+	http.MethodGet + EntryPointGlobalLongShort:       fixed(1),
+	http.MethodGet + EntryPointTakerBuySellVol:       fixed(1),
+}
+
+// Get the request weight of an entry point, an unknown entry point weighs 1
+func RequestWeight(method, entryPoint string, params url.Values) int64 {
+	if weight, ok := weights[method+entryPoint]; ok {
+		return weight(params)
+	}
+	return 1
+}
+
+// Set the request weight of an entry point from the weight table,
+// it must follow the options setting the method and the parameters.
+func Weight(entryPoint string) rpc.RequestOption {
+	return func(req *rpc.Request) {
+		rpc.SetWeight(RequestWeight(req.Method(), entryPoint, req.Params()))(req)
+	}
+}
@@ -0,0 +1,34 @@
+package delivery
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/h9896/bingo/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestWeight(t *testing.T) {
+	assert.EqualValues(t, 30, RequestWeight(http.MethodGet, EntryPointPositionMode, nil))
+	assert.EqualValues(t, 1, RequestWeight(http.MethodPost, EntryPointPositionMode, nil))
+	assert.EqualValues(t, 20, RequestWeight(http.MethodGet, EntryPointAllOrders, url.Values{"symbol": {"BTCUSD_PERP"}}))
+	assert.EqualValues(t, 40, RequestWeight(http.MethodGet, EntryPointAllOrders, url.Values{"pair": {"BTCUSD"}}))
+	assert.EqualValues(t, 2, RequestWeight(http.MethodGet, EntryPointOrderBook, url.Values{"limit": {"5"}}))
+	assert.EqualValues(t, 5, RequestWeight(http.MethodGet, EntryPointOrderBook, url.Values{"limit": {"100"}}))
+	assert.EqualValues(t, 10, RequestWeight(http.MethodGet, EntryPointOrderBook, nil))
+	assert.EqualValues(t, 20, RequestWeight(http.MethodGet, EntryPointOrderBook, url.Values{"limit": {"1000"}}))
+	assert.EqualValues(t, 1, RequestWeight(http.MethodGet, EntryPointKlines, url.Values{"limit": {"99"}}))
+	assert.EqualValues(t, 5, RequestWeight(http.MethodGet, EntryPointContinuousKlines, nil))
+	assert.EqualValues(t, 10, RequestWeight(http.MethodGet, EntryPointMarkPriceKlines, url.Values{"limit": {"1500"}}))
+	assert.EqualValues(t, 40, RequestWeight(http.MethodGet, EntryPointTicker24hr, nil))
+	assert.EqualValues(t, 1, RequestWeight(http.MethodGet, "dapi/v1/unknown", nil))
+}
+
+func TestWeight(t *testing.T) {
+	client := rpc.NewGenericHttpClient("apikey", true, nil)
+	req := client.GetHttpRequest(rpc.SetMethod("get"),
+		rpc.SetParams(&rpc.HttpParameter{Key: "limit", Val: "1000"}),
+		Weight(EntryPointOrderBook))
+	assert.EqualValues(t, 20, req.Weight())
+}
@@ -0,0 +1,21 @@
+package events
+
+import "encoding/json"
+
+// AccountConfigUpdateMsg is pushed by the user data stream when the leverage of a symbol changes
+type AccountConfigUpdateMsg struct {
+	EventType       string         `json:"e,omitempty"`
+	EventTime       int64          `json:"E,omitempty"`
+	TransactionTime int64          `json:"T,omitempty"`
+	AccountAlias    string         `json:"i,omitempty"`
+	Data            LeverageConfig `json:"ac,omitempty"`
+}
+
+type LeverageConfig struct {
+	Symbol   string `json:"s,omitempty"`
+	Leverage int64  `json:"l,omitempty"`
+}
+
+func (msg *AccountConfigUpdateMsg) Unmarshal(in []byte) error {
+	return json.Unmarshal(in, msg)
+}
@@ -0,0 +1,43 @@
+package events
+
+import "encoding/json"
+
+// AccountUpdateMsg is pushed by the user data stream when a balance or a position changes
+type AccountUpdateMsg struct {
+	EventType       string        `json:"e,omitempty"`
+	EventTime       int64         `json:"E,omitempty"`
+	TransactionTime int64         `json:"T,omitempty"`
+	AccountAlias    string        `json:"i,omitempty"`
+	Data            AccountUpdate `json:"a,omitempty"`
+}
+
+type AccountUpdate struct {
+	// ORDER, FUNDING_FEE, DEPOSIT, WITHDRAW, ...
+	Reason    string           `json:"m,omitempty"`
+	Balances  []BalanceUpdate  `json:"B,omitempty"`
+	Positions []PositionUpdate `json:"P,omitempty"`
+}
+
+type BalanceUpdate struct {
+	Asset         string `json:"a,omitempty"`
+	WalletBalance string `json:"wb,omitempty"`
+	CrossWallet   string `json:"cw,omitempty"`
+	// Balance change except PnL and commission
+	BalanceChange string `json:"bc,omitempty"`
+}
+
+type PositionUpdate struct {
+	Symbol              string `json:"s,omitempty"`
+	PositionAmount      string `json:"pa,omitempty"`
+	EntryPrice          string `json:"ep,omitempty"`
+	BreakevenPrice      string `json:"bep,omitempty"`
+	AccumulatedRealized string `json:"cr,omitempty"`
+	UnrealizedPnL       string `json:"up,omitempty"`
+	MarginType          string `json:"mt,omitempty"`
+	IsolatedWallet      string `json:"iw,omitempty"`
+	PositionSide        string `json:"ps,omitempty"`
+}
+
+func (msg *AccountUpdateMsg) Unmarshal(in []byte) error {
+	return json.Unmarshal(in, msg)
+}
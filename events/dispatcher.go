@@ -0,0 +1,155 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The event types of the COIN-M futures streams
+const (
+	EventAggTrade            = "aggTrade"
+	EventKLine               = "kline"
+	EventContinuousKLine     = "continuous_kline"
+	EventMarkPrice           = "markPriceUpdate"
+	EventIndexPrice          = "indexPriceUpdate"
+	EventMiniTicker          = "24hrMiniTicker"
+	EventTicker              = "24hrTicker"
+	EventBookTicker          = "bookTicker"
+	EventDepthUpdate         = "depthUpdate"
+	EventForceOrder          = "forceOrder"
+	EventOrderTradeUpdate    = "ORDER_TRADE_UPDATE"
+	EventAccountUpdate       = "ACCOUNT_UPDATE"
+	EventMarginCall          = "MARGIN_CALL"
+	EventAccountConfigUpdate = "ACCOUNT_CONFIG_UPDATE"
+	EventListenKeyExpired    = "listenKeyExpired"
+)
+
+// Dispatcher routes the raw messages of a stream to the typed callbacks of their event type.
+// Register the callbacks before the messages are dispatched.
+type Dispatcher struct {
+	handlers map[string]func(in []byte) error
+	unknown  func(msg []byte)
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		handlers: map[string]func(in []byte) error{},
+		unknown:  func(msg []byte) {},
+	}
+}
+
+// The event type of a message, a message of a combined stream is wrapped in {"stream":...,"data":...}
+type envelope struct {
+	EventType string          `json:"e"`
+	EventTime int64           `json:"E"`
+	Stream    string          `json:"stream"`
+	Data      json.RawMessage `json:"data"`
+}
+
+func on[T any, PT interface {
+	*T
+	EventHandler
+}](d *Dispatcher, eventType string, handler func(PT)) *Dispatcher {
+	d.handlers[eventType] = func(in []byte) error {
+		msg := PT(new(T))
+		if err := msg.Unmarshal(in); err != nil {
+			return fmt.Errorf("unmarshal %s event: %w", eventType, err)
+		}
+		handler(msg)
+		return nil
+	}
+	return d
+}
+
+func (d *Dispatcher) OnAggTrade(handler func(*AggregateMsg)) *Dispatcher {
+	return on(d, EventAggTrade, handler)
+}
+
+func (d *Dispatcher) OnKLine(handler func(*KLineMsg)) *Dispatcher {
+	return on(d, EventKLine, handler)
+}
+
+func (d *Dispatcher) OnContinuousKLine(handler func(*KLineContractMsg)) *Dispatcher {
+	return on(d, EventContinuousKLine, handler)
+}
+
+func (d *Dispatcher) OnMarkPrice(handler func(*MarkPriceMsg)) *Dispatcher {
+	return on(d, EventMarkPrice, handler)
+}
+
+func (d *Dispatcher) OnIndexPrice(handler func(*IndexPriceMsg)) *Dispatcher {
+	return on(d, EventIndexPrice, handler)
+}
+
+func (d *Dispatcher) OnMiniTicker(handler func(*MinTickertMsg)) *Dispatcher {
+	return on(d, EventMiniTicker, handler)
+}
+
+func (d *Dispatcher) OnTicker(handler func(*IndividualSymbolTickertMsg)) *Dispatcher {
+	return on(d, EventTicker, handler)
+}
+
+func (d *Dispatcher) OnBookTicker(handler func(*BookTickertMsg)) *Dispatcher {
+	return on(d, EventBookTicker, handler)
+}
+
+func (d *Dispatcher) OnPartialBookDepth(handler func(*PartialBookDepthMsg)) *Dispatcher {
+	return on(d, EventDepthUpdate, handler)
+}
+
+func (d *Dispatcher) OnLiquidateOrder(handler func(*LiquidateOrderMsg)) *Dispatcher {
+	return on(d, EventForceOrder, handler)
+}
+
+func (d *Dispatcher) OnOrderTradeUpdate(handler func(*OrderTradeUpdateMsg)) *Dispatcher {
+	return on(d, EventOrderTradeUpdate, handler)
+}
+
+func (d *Dispatcher) OnAccountUpdate(handler func(*AccountUpdateMsg)) *Dispatcher {
+	return on(d, EventAccountUpdate, handler)
+}
+
+func (d *Dispatcher) OnMarginCall(handler func(*MarginCallMsg)) *Dispatcher {
+	return on(d, EventMarginCall, handler)
+}
+
+func (d *Dispatcher) OnAccountConfigUpdate(handler func(*AccountConfigUpdateMsg)) *Dispatcher {
+	return on(d, EventAccountConfigUpdate, handler)
+}
+
+func (d *Dispatcher) OnListenKeyExpired(handler func(*ListenKeyExpiredMsg)) *Dispatcher {
+	return on(d, EventListenKeyExpired, handler)
+}
+
+// Receive the messages without a callback of their event type, e.g. the responses of the
+// subscriptions, the array streams and the unknown events. The payload of a combined stream is unwrapped.
+func (d *Dispatcher) OnUnknown(handler func(msg []byte)) *Dispatcher {
+	d.unknown = handler
+	return d
+}
+
+// Unmarshal a message into the type of its event and invoke the callback,
+// the message which is not a json object is passed to the unknown callback
+func (d *Dispatcher) Dispatch(msg []byte) error {
+	env := envelope{}
+	if err := json.Unmarshal(msg, &env); err != nil {
+		d.unknown(msg)
+		return nil
+	}
+
+	if env.Stream != "" && len(env.Data) > 0 {
+		msg = env.Data
+		env = envelope{}
+		if err := json.Unmarshal(msg, &env); err != nil {
+			d.unknown(msg)
+			return nil
+		}
+	}
+
+	handler, ok := d.handlers[env.EventType]
+	if !ok {
+		d.unknown(msg)
+		return nil
+	}
+	return handler(msg)
+}
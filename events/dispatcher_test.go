@@ -0,0 +1,55 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatcher(t *testing.T) {
+	var aggTrade *AggregateMsg
+	var order *OrderTradeUpdateMsg
+	var expired *ListenKeyExpiredMsg
+	unknown := [][]byte{}
+
+	d := NewDispatcher().
+		OnAggTrade(func(msg *AggregateMsg) { aggTrade = msg }).
+		OnOrderTradeUpdate(func(msg *OrderTradeUpdateMsg) { order = msg }).
+		OnListenKeyExpired(func(msg *ListenKeyExpiredMsg) { expired = msg }).
+		OnUnknown(func(msg []byte) { unknown = append(unknown, msg) })
+
+	data := `{"e":"aggTrade","E":1591261134288,"a":424951,"s":"BTCUSD_200626","p":"9643.5","q":"2","f":606073,"l":606073,"T":1591261134199,"m":false}`
+	assert.Nil(t, d.Dispatch([]byte(data)))
+	assert.EqualValues(t, 424951, aggTrade.AggregateTradeID)
+	assert.EqualValues(t, "9643.5", aggTrade.Price)
+
+	// The payload of a combined stream is unwrapped
+	aggTrade = nil
+	assert.Nil(t, d.Dispatch([]byte(fmt.Sprintf(`{"stream":"btcusd_200626@aggTrade","data":%s}`, data))))
+	assert.EqualValues(t, "BTCUSD_200626", aggTrade.Symbol)
+
+	assert.Nil(t, d.Dispatch(loadFixture(t, "order_trade_update.json")))
+	assert.EqualValues(t, 8888888, order.Data.OrderId)
+
+	assert.Nil(t, d.Dispatch(loadFixture(t, "listen_key_expired.json")))
+	assert.EqualValues(t, 1576653824250, expired.EventTime)
+	assert.Empty(t, unknown)
+
+	// The messages without a callback fall back to the unknown callback
+	assert.Nil(t, d.Dispatch(loadFixture(t, "margin_call.json")))
+	assert.Nil(t, d.Dispatch([]byte(`{"result":null,"id":1}`)))
+	assert.Nil(t, d.Dispatch([]byte(`{"stream":"!markPrice@arr","data":[{"e":"markPriceUpdate"}]}`)))
+	assert.Len(t, unknown, 3)
+	assert.EqualValues(t, `[{"e":"markPriceUpdate"}]`, string(unknown[2]))
+}
+
+func TestDispatcherUnmarshalError(t *testing.T) {
+	d := NewDispatcher().OnAggTrade(func(msg *AggregateMsg) {
+		t.Fatal("an invalid message is dispatched")
+	})
+
+	err := d.Dispatch([]byte(`{"e":"aggTrade","E":1591261134288,"a":"424951"}`))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "aggTrade")
+}
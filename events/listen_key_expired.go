@@ -0,0 +1,14 @@
+package events
+
+import "encoding/json"
+
+// ListenKeyExpiredMsg is pushed by the user data stream when its listenKey expires, the stream is closed after it
+type ListenKeyExpiredMsg struct {
+	EventType string `json:"e,omitempty"`
+	EventTime int64  `json:"E,omitempty"`
+	ListenKey string `json:"listenKey,omitempty"`
+}
+
+func (msg *ListenKeyExpiredMsg) Unmarshal(in []byte) error {
+	return json.Unmarshal(in, msg)
+}
@@ -0,0 +1,27 @@
+package events
+
+import "encoding/json"
+
+// MarginCallMsg is pushed by the user data stream when the margin ratio of positions is high
+type MarginCallMsg struct {
+	EventType    string           `json:"e,omitempty"`
+	EventTime    int64            `json:"E,omitempty"`
+	AccountAlias string           `json:"i,omitempty"`
+	CrossWallet  string           `json:"cw,omitempty"`
+	Positions    []MarginPosition `json:"p,omitempty"`
+}
+
+type MarginPosition struct {
+	Symbol            string `json:"s,omitempty"`
+	PositionSide      string `json:"ps,omitempty"`
+	PositionAmount    string `json:"pa,omitempty"`
+	MarginType        string `json:"mt,omitempty"`
+	IsolatedWallet    string `json:"iw,omitempty"`
+	MarkPrice         string `json:"mp,omitempty"`
+	UnrealizedPnL     string `json:"up,omitempty"`
+	MaintenanceMargin string `json:"mm,omitempty"`
+}
+
+func (msg *MarginCallMsg) Unmarshal(in []byte) error {
+	return json.Unmarshal(in, msg)
+}
@@ -0,0 +1,51 @@
+package events
+
+import "encoding/json"
+
+// OrderTradeUpdateMsg is pushed by the user data stream when an order is created or its status changes
+type OrderTradeUpdateMsg struct {
+	EventType       string      `json:"e,omitempty"`
+	EventTime       int64       `json:"E,omitempty"`
+	TransactionTime int64       `json:"T,omitempty"`
+	AccountAlias    string      `json:"i,omitempty"`
+	Data            OrderUpdate `json:"o,omitempty"`
+}
+
+type OrderUpdate struct {
+	Symbol              string `json:"s,omitempty"`
+	ClientOrderId       string `json:"c,omitempty"`
+	Side                string `json:"S,omitempty"`
+	OrderType           string `json:"o,omitempty"`
+	TimeInForce         string `json:"f,omitempty"`
+	OriginalQuantity    string `json:"q,omitempty"`
+	OriginalPrice       string `json:"p,omitempty"`
+	AveragePrice        string `json:"ap,omitempty"`
+	StopPrice           string `json:"sp,omitempty"`
+	ExecutionType       string `json:"x,omitempty"`
+	OrderStatus         string `json:"X,omitempty"`
+	OrderId             int64  `json:"i,omitempty"`
+	LastFilledQuantity  string `json:"l,omitempty"`
+	AccumulatedQuantity string `json:"z,omitempty"`
+	LastFilledPrice     string `json:"L,omitempty"`
+	MarginAsset         string `json:"ma,omitempty"`
+	CommissionAsset     string `json:"N,omitempty"`
+	Commission          string `json:"n,omitempty"`
+	TradeTime           int64  `json:"T,omitempty"`
+	TradeId             int64  `json:"t,omitempty"`
+	RealizedProfit      string `json:"rp,omitempty"`
+	BidsNotional        string `json:"b,omitempty"`
+	AsksNotional        string `json:"a,omitempty"`
+	IsMaker             bool   `json:"m,omitempty"`
+	ReduceOnly          bool   `json:"R,omitempty"`
+	WorkingType         string `json:"wt,omitempty"`
+	OriginalOrderType   string `json:"ot,omitempty"`
+	PositionSide        string `json:"ps,omitempty"`
+	ClosePosition       bool   `json:"cp,omitempty"`
+	ActivationPrice     string `json:"AP,omitempty"`
+	CallbackRate        string `json:"cr,omitempty"`
+	PriceProtect        bool   `json:"pP,omitempty"`
+}
+
+func (msg *OrderTradeUpdateMsg) Unmarshal(in []byte) error {
+	return json.Unmarshal(in, msg)
+}
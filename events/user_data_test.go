@@ -0,0 +1,102 @@
+package events
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadFixture(t *testing.T, name string) []byte {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestOrderTradeUpdateMsg(t *testing.T) {
+	msg := &OrderTradeUpdateMsg{}
+	assert.Nil(t, msg.Unmarshal(loadFixture(t, "order_trade_update.json")))
+	assert.EqualValues(t, "ORDER_TRADE_UPDATE", msg.EventType)
+	assert.EqualValues(t, 1591274595442, msg.EventTime)
+	assert.EqualValues(t, 1591274595453, msg.TransactionTime)
+	assert.EqualValues(t, "SfsR", msg.AccountAlias)
+	assert.EqualValues(t, "BTCUSD_200925", msg.Data.Symbol)
+	assert.EqualValues(t, "TEST", msg.Data.ClientOrderId)
+	assert.EqualValues(t, "SELL", msg.Data.Side)
+	assert.EqualValues(t, "TRAILING_STOP_MARKET", msg.Data.OrderType)
+	assert.EqualValues(t, "GTC", msg.Data.TimeInForce)
+	assert.EqualValues(t, "2", msg.Data.OriginalQuantity)
+	assert.EqualValues(t, "9103.1", msg.Data.StopPrice)
+	assert.EqualValues(t, "NEW", msg.Data.ExecutionType)
+	assert.EqualValues(t, "NEW", msg.Data.OrderStatus)
+	assert.EqualValues(t, 8888888, msg.Data.OrderId)
+	assert.EqualValues(t, "BTC", msg.Data.MarginAsset)
+	assert.EqualValues(t, "BTC", msg.Data.CommissionAsset)
+	assert.EqualValues(t, 1591274595442, msg.Data.TradeTime)
+	assert.EqualValues(t, "CONTRACT_PRICE", msg.Data.WorkingType)
+	assert.EqualValues(t, "TRAILING_STOP_MARKET", msg.Data.OriginalOrderType)
+	assert.EqualValues(t, "LONG", msg.Data.PositionSide)
+	assert.EqualValues(t, "9476.8", msg.Data.ActivationPrice)
+	assert.EqualValues(t, "5.0", msg.Data.CallbackRate)
+	assert.False(t, msg.Data.IsMaker)
+}
+
+func TestAccountUpdateMsg(t *testing.T) {
+	msg := &AccountUpdateMsg{}
+	assert.Nil(t, msg.Unmarshal(loadFixture(t, "account_update.json")))
+	assert.EqualValues(t, "ACCOUNT_UPDATE", msg.EventType)
+	assert.EqualValues(t, 1564745798938, msg.TransactionTime)
+	assert.EqualValues(t, "ORDER", msg.Data.Reason)
+	assert.EqualValues(t, []BalanceUpdate{
+		{Asset: "BTC", WalletBalance: "122624.12345678", CrossWallet: "100.12345678", BalanceChange: "50.12345678"},
+		{Asset: "ETH", WalletBalance: "1.00000000", CrossWallet: "0.00000000", BalanceChange: "-49.12345678"},
+	}, msg.Data.Balances)
+	assert.Len(t, msg.Data.Positions, 2)
+	assert.EqualValues(t, PositionUpdate{
+		Symbol:              "BTCUSD_200925",
+		PositionAmount:      "20",
+		EntryPrice:          "6563.6",
+		BreakevenPrice:      "6563.7",
+		AccumulatedRealized: "0",
+		UnrealizedPnL:       "2850.21200000",
+		MarginType:          "isolated",
+		IsolatedWallet:      "13200.70726908",
+		PositionSide:        "LONG",
+	}, msg.Data.Positions[1])
+}
+
+func TestMarginCallMsg(t *testing.T) {
+	msg := &MarginCallMsg{}
+	assert.Nil(t, msg.Unmarshal(loadFixture(t, "margin_call.json")))
+	assert.EqualValues(t, "MARGIN_CALL", msg.EventType)
+	assert.EqualValues(t, "3.16812045", msg.CrossWallet)
+	assert.EqualValues(t, []MarginPosition{{
+		Symbol:            "BTCUSD_200925",
+		PositionSide:      "LONG",
+		PositionAmount:    "132",
+		MarginType:        "CROSSED",
+		IsolatedWallet:    "0",
+		MarkPrice:         "9187.17127000",
+		UnrealizedPnL:     "-1.166074",
+		MaintenanceMargin: "1.614445",
+	}}, msg.Positions)
+}
+
+func TestAccountConfigUpdateMsg(t *testing.T) {
+	msg := &AccountConfigUpdateMsg{}
+	assert.Nil(t, msg.Unmarshal(loadFixture(t, "account_config_update.json")))
+	assert.EqualValues(t, "ACCOUNT_CONFIG_UPDATE", msg.EventType)
+	assert.EqualValues(t, 1611646737476, msg.TransactionTime)
+	assert.EqualValues(t, LeverageConfig{Symbol: "BTCUSD_PERP", Leverage: 25}, msg.Data)
+}
+
+func TestListenKeyExpiredMsg(t *testing.T) {
+	msg := &ListenKeyExpiredMsg{}
+	assert.Nil(t, msg.Unmarshal(loadFixture(t, "listen_key_expired.json")))
+	assert.EqualValues(t, "listenKeyExpired", msg.EventType)
+	assert.EqualValues(t, 1576653824250, msg.EventTime)
+	assert.EqualValues(t, "WsCMN0a4KHUPTQuX6IUnqEZfB1inxmv1qR4kbf1LuEjur5VdbzqvyxqG9TSjVVxv", msg.ListenKey)
+}
@@ -0,0 +1,77 @@
+package profile
+
+// Endpoints of a market
+type Endpoints struct {
+	// Domain of the REST API, e.g. dapi.binance.com
+	RestDomain string
+	// Host of the WebSocket streams, e.g. dstream.binance.com
+	WsBaseURL string
+	// Prefix of the REST API, e.g. dapi/v1
+	APIPrefix string
+	// Use https and wss instead of http and ws
+	UseSSL bool
+}
+
+// Profile bundles the endpoints of COIN-M futures, USD-M futures and Spot of an environment
+type Profile struct {
+	Name  string
+	CoinM Endpoints
+	USDM  Endpoints
+	Spot  Endpoints
+}
+
+var (
+	Production = Profile{
+		Name: "production",
+		CoinM: Endpoints{
+			RestDomain: "dapi.binance.com",
+			WsBaseURL:  "dstream.binance.com",
+			APIPrefix:  "dapi/v1",
+			UseSSL:     true,
+		},
+		USDM: Endpoints{
+			RestDomain: "fapi.binance.com",
+			WsBaseURL:  "fstream.binance.com",
+			APIPrefix:  "fapi/v1",
+			UseSSL:     true,
+		},
+		Spot: Endpoints{
+			RestDomain: "api.binance.com",
+			WsBaseURL:  "stream.binance.com:9443",
+			APIPrefix:  "api/v3",
+			UseSSL:     true,
+		},
+	}
+
+	Testnet = Profile{
+		Name: "testnet",
+		CoinM: Endpoints{
+			RestDomain: "testnet.binancefuture.com",
+			WsBaseURL:  "dstream.binancefuture.com",
+			APIPrefix:  "dapi/v1",
+			UseSSL:     true,
+		},
+		USDM: Endpoints{
+			RestDomain: "testnet.binancefuture.com",
+			WsBaseURL:  "stream.binancefuture.com",
+			APIPrefix:  "fapi/v1",
+			UseSSL:     true,
+		},
+		Spot: Endpoints{
+			RestDomain: "testnet.binance.vision",
+			WsBaseURL:  "testnet.binance.vision",
+			APIPrefix:  "api/v3",
+			UseSSL:     true,
+		},
+	}
+)
+
+// Create a profile of a custom environment, e.g. a proxy or a mock server
+func Custom(name string, coinM, usdM, spot Endpoints) Profile {
+	return Profile{
+		Name:  name,
+		CoinM: coinM,
+		USDM:  usdM,
+		Spot:  spot,
+	}
+}
@@ -6,11 +6,15 @@ import (
 )
 
 type GenericHttpClient interface {
-	// Execute a http request
-	ExecuteHttpOperation(ctx context.Context, request *reqMsg) (*http.Response, error)
+	// Execute a http request, a response with a status code of 400 or above is returned without an error
+	// and its body is readable, only the interceptors see it with an APIError
+	ExecuteHttpOperation(ctx context.Context, request *Request) (*http.Response, error)
 
 	// Create a http request
-	GetHttpRequest(opts ...RequestOption) *reqMsg
+	GetHttpRequest(opts ...RequestOption) *Request
+
+	// Get the rate limit usage reported by the exchange
+	GetRateLimitUsage() RateLimitUsage
 }
 
 type HTTPClient interface {
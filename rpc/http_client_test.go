@@ -1,10 +1,22 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/h9896/bingo/mocks"
 	"github.com/stretchr/testify/assert"
@@ -12,7 +24,7 @@ import (
 
 func TestRequestOption(t *testing.T) {
 
-	req := &reqMsg{}
+	req := &Request{}
 	SetEndpoint("api/v1")(req)
 	assert.EqualValues(t, "api/v1", req.endpoint)
 
@@ -113,3 +125,529 @@ func TestExecuteHttpOperation(t *testing.T) {
 	assert.EqualValues(t, req.method, resp.Request.Method)
 	assert.EqualValues(t, req.fullURL, resp.Request.URL.String())
 }
+
+func TestNewAPIError(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Body:       ioutil.NopCloser(bytes.NewBufferString(`{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`)),
+	}
+	var err error = NewAPIError(resp, "dapi.binance.com/dapi/v1/order")
+
+	apiErr := &APIError{}
+	assert.True(t, errors.As(err, &apiErr))
+	assert.EqualValues(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.EqualValues(t, -1021, apiErr.Code)
+	assert.EqualValues(t, "Timestamp for this request is outside of the recvWindow.", apiErr.Message)
+	assert.EqualValues(t, "dapi.binance.com/dapi/v1/order", apiErr.Endpoint)
+
+	resp = &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("Bad Gateway")),
+	}
+	apiErr = NewAPIError(resp, "dapi.binance.com/dapi/v1/order")
+	assert.EqualValues(t, 0, apiErr.Code)
+	assert.EqualValues(t, "Bad Gateway", apiErr.Message)
+}
+
+func TestExecuteHttpOperationWithServerTimeSync(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{},
+		WithServerTimeSync("dapi.binance.com/dapi/v1/time", time.Hour))
+
+	offset := 10 * time.Second
+	syncCount := 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		if req.URL.Path == "/dapi/v1/time" {
+			syncCount++
+			data := fmt.Sprintf(`{"serverTime": %d}`, time.Now().Add(offset).UnixMilli())
+			resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+			return
+		}
+		timestamp, _ := strconv.ParseInt(req.URL.Query().Get(timestampKey), 10, 64)
+		assert.InDelta(t, time.Now().Add(offset).UnixMilli(), timestamp, 1000)
+		data := `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`
+		resp = &http.Response{StatusCode: 400, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"),
+			SetPrivate(),
+			SetMethod("get"),
+			SetTimestamp(),
+			SetSignature("secret"))
+		resp, err := client.ExecuteHttpOperation(context.Background(), req)
+		assert.Nil(t, err)
+		// The body is still readable after checking the error code
+		assert.EqualValues(t, -1021, NewAPIError(resp, req.endpoint).Code)
+	}
+
+	// The -1021 error forces a refresh although the interval is not reached
+	assert.EqualValues(t, 2, syncCount)
+}
+
+func TestRateLimitUsage(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{},
+		WithRateLimitBudget(RateLimitBudget{Interval: "1m", MaxWeight: 2400, MaxOrders: 1200}))
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		header := http.Header{}
+		header.Set("X-MBX-USED-WEIGHT-1M", "2399")
+		header.Set("X-MBX-ORDER-COUNT-1M", "3")
+		resp = &http.Response{StatusCode: 200, Header: header, Request: req}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/depth"), SetMethod("get"))
+	_, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+
+	usage := client.GetRateLimitUsage()
+	assert.EqualValues(t, 2399, usage.UsedWeight["1M"])
+	assert.EqualValues(t, 3, usage.OrderCount["1M"])
+
+	// The next request costs 1 weight and fits in the budget
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/depth"), SetMethod("get"))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+
+	// A heavier request fails fast
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/depth"), SetMethod("get"), SetWeight(20))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.ErrorIs(t, err, ErrRateLimitExceeded)
+}
+
+func TestRateLimitBudgetWait(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{},
+		WithRateLimitBudget(RateLimitBudget{Interval: "1M", MaxOrders: 10, Wait: true}))
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		header := http.Header{}
+		header.Set("X-MBX-ORDER-COUNT-1M", "10")
+		resp = &http.Response{StatusCode: 200, Header: header, Request: req}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"), SetOrderCount(1))
+	_, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+
+	// A request without order is not blocked by the order budget
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("get"))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+
+	// Blocks until the next minute, which is longer than the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"), SetOrderCount(1))
+	_, err = client.ExecuteHttpOperation(ctx, req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestExecuteHttpOperationWithRetry(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{},
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}))
+
+	attempts := 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		attempts++
+		switch attempts {
+		case 1:
+			err = &url.Error{Op: "Get", URL: req.URL.String(), Err: syscall.ECONNRESET}
+		case 2:
+			resp = &http.Response{StatusCode: http.StatusServiceUnavailable, Body: ioutil.NopCloser(bytes.NewBufferString(""))}
+		default:
+			resp = &http.Response{StatusCode: http.StatusOK, Request: req}
+		}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/openOrders"), SetMethod("get"))
+	resp, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 3, attempts)
+
+	// A non-idempotent request is sent once
+	attempts = 0
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, 1, attempts)
+
+	// Unless it is marked as safe
+	attempts = 0
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"), SetRetrySafe())
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 3, attempts)
+}
+
+func TestRetryPolicyErrors(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	for _, err := range []error{
+		&url.Error{Op: "Get", URL: "http://mock", Err: syscall.ECONNRESET},
+		&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED},
+		io.ErrUnexpectedEOF,
+		fmt.Errorf("read: %w", syscall.ECONNRESET),
+		&url.Error{Op: "Get", URL: "http://mock", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}},
+	} {
+		_, retry := policy.backoff(1, nil, err)
+		assert.True(t, retry, err.Error())
+	}
+
+	// The errors of the client and the context are not retried
+	for _, err := range []error{
+		fmt.Errorf("%w: used weight 2400 + 1 > 2400 in 1M", ErrRateLimitExceeded),
+		context.Canceled,
+		&url.Error{Op: "Get", URL: "http://mock", Err: context.DeadlineExceeded},
+		errors.New("sign: invalid key"),
+		&url.Error{Op: "Get", URL: "https://mock", Err: x509.UnknownAuthorityError{}},
+		&url.Error{Op: "Get", URL: "https://mock", Err: errors.New("stopped after 10 redirects")},
+		&url.Error{Op: "Get", URL: "ftp://mock", Err: errors.New(`unsupported protocol scheme "ftp"`)},
+	} {
+		_, retry := policy.backoff(1, nil, err)
+		assert.False(t, retry, err.Error())
+	}
+}
+
+func TestExecuteHttpOperationWithRetryAfter(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{})
+
+	attempts := 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		attempts++
+		header := http.Header{}
+		header.Set("Retry-After", "30")
+		resp = &http.Response{StatusCode: http.StatusTooManyRequests, Header: header, Body: ioutil.NopCloser(bytes.NewBufferString(""))}
+		return
+	}
+
+	// Waiting 30 seconds exceeds the deadline, the 429 response is returned
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/depth"), SetMethod("get"), SetRetryPolicy(DefaultRetryPolicy))
+	resp, err := client.ExecuteHttpOperation(ctx, req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.EqualValues(t, 1, attempts)
+
+	// Without a policy there is no retry
+	attempts = 0
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/depth"), SetMethod("get"))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, attempts)
+}
+
+func TestExecuteHttpOperationWithBodyParams(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{}, WithBodyParams())
+
+	body := []*HttpParameter{
+		{Key: "symbol", Val: "BTCUSD_PERP"},
+		{Key: "side", Val: "BUY"},
+		{Key: "type", Val: "LIMIT"},
+		{Key: "quantity", Val: "1"},
+		{Key: "price", Val: "28000.1"}}
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"),
+		SetPrivate(),
+		SetMethod("post"),
+		SetParams(body...),
+		SetTimestamp(),
+		SetSignature("secret"))
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.EqualValues(t, "", req.URL.RawQuery)
+		assert.EqualValues(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+
+		raw, _ := ioutil.ReadAll(req.Body)
+		form, err := url.ParseQuery(string(raw))
+		assert.Nil(t, err)
+		assert.EqualValues(t, "BTCUSD_PERP", form.Get("symbol"))
+		assert.EqualValues(t, "28000.1", form.Get("price"))
+
+		// The signature is computed over the body without the signature
+		signed := string(raw)
+		payload := signed[:strings.LastIndex(signed, "&"+signatureKey+"=")]
+		expect, _ := NewHMACSigner("secret").Sign([]byte(payload))
+		assert.EqualValues(t, expect, form.Get(signatureKey))
+
+		resp = &http.Response{StatusCode: 200, Request: req}
+		return
+	}
+	_, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+
+	// GET requests keep the parameters and the extra query in the URL
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"),
+		SetPrivate(),
+		SetMethod("get"),
+		SetQuery(&HttpParameter{Key: "symbol", Val: "BTCUSD_PERP"}),
+		SetParams(&HttpParameter{Key: "orderId", Val: "1"}),
+		SetTimestamp(),
+		SetSignature("secret"))
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		assert.Nil(t, req.Body)
+		params := req.URL.Query()
+		assert.EqualValues(t, "BTCUSD_PERP", params.Get("symbol"))
+		assert.EqualValues(t, "1", params.Get("orderId"))
+		mocks.CheckTimestampAndSignature(t, params)
+		resp = &http.Response{StatusCode: 200, Request: req}
+		return
+	}
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+}
+
+func TestRequestAccessors(t *testing.T) {
+	client := NewGenericHttpClient("apikey", true, nil)
+	req := client.GetHttpRequest(
+		SetEndpoint("dapi.binance.com/dapi/v1/order"),
+		SetMethod("delete"),
+		SetHeader(&HttpParameter{Key: "X-Trace-Id", Val: "abc"}),
+		SetParams(&HttpParameter{Key: "symbol", Val: "BTCUSD_PERP"}),
+		SetPrivate())
+
+	assert.EqualValues(t, http.MethodDelete, req.Method())
+	assert.EqualValues(t, "dapi.binance.com/dapi/v1/order", req.Endpoint())
+	assert.EqualValues(t, "BTCUSD_PERP", req.Params().Get("symbol"))
+	assert.EqualValues(t, "abc", req.Header().Get("X-Trace-Id"))
+	assert.True(t, req.IsPrivate())
+
+	// The accessors return copies
+	req.Params().Set("symbol", "ETHUSD_PERP")
+	req.Header().Set("X-Trace-Id", "def")
+	assert.EqualValues(t, "BTCUSD_PERP", req.Params().Get("symbol"))
+	assert.EqualValues(t, "abc", req.Header().Get("X-Trace-Id"))
+
+	clone := req.Clone()
+	SetParams(&HttpParameter{Key: "orderId", Val: "1"})(clone)
+	SetMethod("get")(clone)
+	assert.EqualValues(t, http.MethodGet, clone.Method())
+	assert.EqualValues(t, "1", clone.Params().Get("orderId"))
+	assert.EqualValues(t, http.MethodDelete, req.Method())
+	assert.EqualValues(t, "", req.Params().Get("orderId"))
+}
+
+func TestExecuteHttpOperationWithRecvWindow(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{}, WithRecvWindow(3*time.Second))
+
+	var recvWindow string
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		recvWindow = req.URL.Query().Get("recvWindow")
+		resp = &http.Response{StatusCode: http.StatusOK, Request: req}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/openOrders"), SetMethod("get"), SetTimestamp())
+	_, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "3000", recvWindow)
+
+	// The recvWindow of the request is kept
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/openOrders"), SetMethod("get"),
+		SetParams(&HttpParameter{Key: "recvWindow", Val: "5000"}), SetTimestamp())
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "5000", recvWindow)
+
+	// A request without a timestamp is not affected
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/depth"), SetMethod("get"))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "", recvWindow)
+}
+
+func TestExecuteHttpOperationWithTimeout(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{}, WithTimeout(20*time.Millisecond))
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		deadline, ok := req.Context().Deadline()
+		assert.True(t, ok)
+		if time.Until(deadline) > 20*time.Millisecond {
+			t.Errorf("deadline %v is later than the timeout", deadline)
+		}
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/openOrders"), SetMethod("get"))
+	_, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The body of the response is readable after the timeout is released
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		resp = &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(`{"serverTime":1}`))}
+		return
+	}
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/time"), SetMethod("get"))
+	resp, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.EqualValues(t, `{"serverTime":1}`, string(body))
+}
+
+func TestExecuteHttpOperationWithRecvWindowFromDeadline(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{},
+		WithRecvWindow(5*time.Second), WithRecvWindowFromDeadline())
+
+	var recvWindow int64
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		recvWindow, _ = strconv.ParseInt(req.URL.Query().Get("recvWindow"), 10, 64)
+		resp = &http.Response{StatusCode: http.StatusOK, Request: req}
+		return
+	}
+
+	// The default is used without a deadline
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"), SetTimestamp())
+	_, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 5000, recvWindow)
+
+	// A closer deadline shrinks the recvWindow
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"), SetTimestamp())
+	_, err = client.ExecuteHttpOperation(ctx, req)
+	assert.Nil(t, err)
+	assert.True(t, recvWindow > 0 && recvWindow <= 1000, "recvWindow %d", recvWindow)
+
+	// Including the recvWindow of the request
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"),
+		SetParams(&HttpParameter{Key: "recvWindow", Val: "10000"}), SetTimestamp())
+	_, err = client.ExecuteHttpOperation(ctx, req)
+	assert.Nil(t, err)
+	assert.True(t, recvWindow > 0 && recvWindow <= 1000, "recvWindow %d", recvWindow)
+
+	// A later deadline keeps the default
+	ctx, cancel = context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"), SetTimestamp())
+	_, err = client.ExecuteHttpOperation(ctx, req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 5000, recvWindow)
+
+	// An expired deadline is not sent
+	recvWindow = 0
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"), SetTimestamp())
+	_, err = client.ExecuteHttpOperation(ctx, req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualValues(t, 0, recvWindow)
+}
+
+func TestServerTimeSyncSingleFlight(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{},
+		WithServerTimeSync("dapi.binance.com/dapi/v1/time", time.Hour)).(*GenericHttpSvcClient)
+
+	offset := 10 * time.Second
+	release := make(chan struct{})
+	var syncCount int32
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		if atomic.AddInt32(&syncCount, 1) > 1 {
+			<-release
+		}
+		data := fmt.Sprintf(`{"serverTime": %d}`, time.Now().Add(offset).UnixMilli())
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	assert.InDelta(t, time.Now().Add(offset).UnixMilli(), client.clock.now(context.Background(), client), 1000)
+
+	// A slow refresh does not block the other timestamps, they use the last known offset
+	client.clock.invalidate()
+	refreshed := make(chan struct{})
+	go func() {
+		client.clock.now(context.Background(), client)
+		close(refreshed)
+	}()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&syncCount) == 2
+	}, time.Second, time.Millisecond)
+
+	assert.InDelta(t, time.Now().Add(offset).UnixMilli(), client.clock.now(context.Background(), client), 1000)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&syncCount))
+
+	close(release)
+	<-refreshed
+}
+
+func TestServerTimeSyncBackoff(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{},
+		WithServerTimeSync("dapi.binance.com/dapi/v1/time", time.Hour)).(*GenericHttpSvcClient)
+
+	syncCount := 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		if req.URL.Path == "/dapi/v1/time" {
+			syncCount++
+			resp = &http.Response{StatusCode: 503, Body: ioutil.NopCloser(bytes.NewBufferString("Service Unavailable"))}
+			return
+		}
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString("{}")), Request: req}
+		return
+	}
+
+	// A failing time endpoint is not called again before the backoff
+	for i := 0; i < 5; i++ {
+		req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("get"), SetTimestamp(), SetSignature("secret"))
+		_, err := client.ExecuteHttpOperation(context.Background(), req)
+		assert.Nil(t, err)
+	}
+	assert.EqualValues(t, 1, syncCount)
+
+	// The delay doubles after every failure up to the interval
+	client.clock.failures = 2
+	assert.EqualValues(t, 2*serverTimeRetryDelay, client.clock.retryDelay())
+	client.clock.failures = 100
+	assert.EqualValues(t, time.Hour, client.clock.retryDelay())
+
+	assert.Panics(t, func() {
+		WithServerTimeSync("dapi.binance.com/dapi/v1/time", 0)
+	})
+}
+
+func TestRateLimitBudgetInvalidInterval(t *testing.T) {
+	for _, interval := range []string{"", "M", "0M", "1W"} {
+		assert.Panics(t, func() {
+			WithRateLimitBudget(RateLimitBudget{Interval: interval, MaxWeight: 2400})
+		}, interval)
+	}
+	assert.NotPanics(t, func() {
+		WithRateLimitBudget(RateLimitBudget{Interval: "1m", MaxWeight: 2400})
+	})
+}
+
+func TestRateLimitBudgetWaitTimestamp(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{},
+		WithRateLimitBudget(RateLimitBudget{Interval: "1S", MaxOrders: 1, Wait: true}))
+
+	var nextWindow int64
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		now := time.Now()
+		nextWindow = now.Truncate(time.Second).Add(time.Second).UnixMilli()
+		header := http.Header{}
+		header.Set("X-MBX-ORDER-COUNT-1S", "1")
+		resp = &http.Response{StatusCode: 200, Header: header, Request: req}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"), SetOrderCount(1))
+	_, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+
+	// The request waits for the next second and is stamped after the wait
+	var timestamp int64
+	waitedFor := nextWindow
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		timestamp, _ = strconv.ParseInt(req.URL.Query().Get(timestampKey), 10, 64)
+		resp = &http.Response{StatusCode: 200, Request: req}
+		return
+	}
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"), SetOrderCount(1),
+		SetTimestamp(), SetSignature("secret"))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, timestamp, waitedFor)
+}
@@ -0,0 +1,119 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var jsonPb = &runtime.JSONPb{
+	UnmarshalOptions: protojson.UnmarshalOptions{
+		DiscardUnknown: true,
+	},
+	MarshalOptions: protojson.MarshalOptions{
+		UseProtoNames: true,
+	},
+}
+
+// Execute the request and decode the response into a protobuf message
+func Do[T proto.Message](ctx context.Context, c GenericHttpClient, request *Request) (T, error) {
+	var out T
+	respBody, err := readResponse(ctx, c, request)
+	if err != nil {
+		return out, err
+	}
+
+	msg := out.ProtoReflect().New().Interface().(T)
+	if err := jsonPb.Unmarshal(respBody, msg); err != nil {
+		return out, err
+	}
+	return msg, nil
+}
+
+// Execute the request and decode the response into a list of protobuf messages
+func DoList[T proto.Message](ctx context.Context, c GenericHttpClient, request *Request) ([]T, error) {
+	respBody, err := readResponse(ctx, c, request)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []T{}
+	if err := jsonPb.Unmarshal(respBody, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Execute the request and decode the response with encoding/json
+func DoJSON[T any](ctx context.Context, c GenericHttpClient, request *Request) (T, error) {
+	var out T
+	respBody, err := readResponse(ctx, c, request)
+	if err != nil {
+		return out, err
+	}
+
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		var zero T
+		return zero, err
+	}
+	return out, nil
+}
+
+// Execute the request and read the body of a successful response,
+// a failed response is returned as an APIError
+func readResponse(ctx context.Context, c GenericHttpClient, request *Request) ([]byte, error) {
+	resp, err := c.ExecuteHttpOperation(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, NewAPIError(resp, request.endpoint)
+	}
+	defer resp.Body.Close()
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Result of an item of a batch request, either the value or the error is set
+type BatchResult[T any] struct {
+	Value T
+	Err   error
+}
+
+// Execute a batch request and decode every item of the response into a protobuf message,
+// an item carrying an error payload is returned as an APIError in its slot
+func DoBatch[T proto.Message](ctx context.Context, c GenericHttpClient, request *Request) ([]BatchResult[T], error) {
+	respBody, err := readResponse(ctx, c, request)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []json.RawMessage{}
+	if err := json.Unmarshal(respBody, &items); err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchResult[T], len(items))
+	for i, item := range items {
+		apiErr := &APIError{StatusCode: http.StatusOK, Endpoint: request.endpoint}
+		if err := json.Unmarshal(item, apiErr); err == nil && apiErr.Code < 0 {
+			results[i].Err = apiErr
+			continue
+		}
+
+		var zero T
+		msg := zero.ProtoReflect().New().Interface().(T)
+		if err := jsonPb.Unmarshal(item, msg); err != nil {
+			results[i].Err = err
+			continue
+		}
+		results[i].Value = msg
+	}
+	return results, nil
+}
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	pb "github.com/h9896/bingo-pkg-protobuf/services/delivery/v1"
+	"github.com/h9896/bingo/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type closeRecorder struct {
+	*bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestDo(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{})
+	body := &closeRecorder{Buffer: bytes.NewBufferString(`{"symbol": "BTCUSD_200925", "countdownTime": "100000"}`)}
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		resp = &http.Response{StatusCode: 200, Body: body}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/countdownCancelAll"), SetMethod("post"))
+	out, err := Do[*pb.AutoCancelAllOpenOrdersResponse](context.Background(), client, req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "BTCUSD_200925", out.Symbol)
+	assert.EqualValues(t, 100000, out.CountdownTime)
+	assert.True(t, body.closed)
+}
+
+func TestDoList(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{})
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		data := `[{"asset": "BTC", "balance": "0.00241969"}, {"asset": "ETH", "balance": "1.2"}]`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/balance"), SetMethod("get"))
+	out, err := DoList[*pb.Balance](context.Background(), client, req)
+	assert.Nil(t, err)
+	assert.Len(t, out, 2)
+	assert.EqualValues(t, "ETH", out[1].Asset)
+	assert.EqualValues(t, 1.2, out[1].Balance)
+}
+
+func TestDoJSON(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{})
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"serverTime": 1499827319559}`))}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/time"), SetMethod("get"))
+	out, err := DoJSON[serverTime](context.Background(), client, req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1499827319559, out.ServerTime)
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		resp = &http.Response{StatusCode: 400, Body: ioutil.NopCloser(bytes.NewBufferString(`{"code": -1100, "msg": "Illegal characters found in a parameter."}`))}
+		return
+	}
+	_, err = DoJSON[serverTime](context.Background(), client, req)
+	apiErr := &APIError{}
+	assert.True(t, errors.As(err, &apiErr))
+	assert.EqualValues(t, -1100, apiErr.Code)
+	assert.EqualValues(t, "dapi.binance.com/dapi/v1/time", apiErr.Endpoint)
+}
+
+func TestDoBatch(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{})
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		data := `[{"asset": "BTC", "balance": "0.00241969"}, {"code": -2011, "msg": "Unknown order sent."}, "invalid"]`
+		resp = &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/batchOrders"), SetMethod("delete"))
+	out, err := DoBatch[*pb.Balance](context.Background(), client, req)
+	assert.Nil(t, err)
+	assert.Len(t, out, 3)
+	assert.Nil(t, out[0].Err)
+	assert.EqualValues(t, "BTC", out[0].Value.Asset)
+
+	var apiErr *APIError
+	assert.True(t, errors.As(out[1].Err, &apiErr))
+	assert.EqualValues(t, -2011, apiErr.Code)
+	assert.EqualValues(t, "dapi.binance.com/dapi/v1/batchOrders", apiErr.Endpoint)
+	assert.NotNil(t, out[2].Err)
+}
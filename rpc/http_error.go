@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// APIError is the error payload returned by binance, e.g. {"code":-1021,"msg":"..."}
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       int64  `json:"code"`
+	Message    string `json:"msg"`
+	Endpoint   string `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("<APIError> status=%d, code=%d, msg=%s, endpoint=%s", e.StatusCode, e.Code, e.Message, e.Endpoint)
+}
+
+// Parse the error payload of a failed response and close its body.
+// When the body is not a binance error payload, the raw body is kept as the message.
+func NewAPIError(resp *http.Response, endpoint string) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Endpoint:   endpoint,
+	}
+
+	if resp.Body == nil {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+		return apiErr
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		apiErr.Message = err.Error()
+		return apiErr
+	}
+
+	if err := json.Unmarshal(respBody, apiErr); err != nil || (apiErr.Code == 0 && apiErr.Message == "") {
+		apiErr.Message = string(respBody)
+	}
+
+	return apiErr
+}
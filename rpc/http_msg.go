@@ -1,8 +1,6 @@
 package rpc
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -15,19 +13,86 @@ const (
 	signatureKey = "signature"
 )
 
-type reqMsg struct {
-	method     string
-	endpoint   string
-	private    bool
-	signature  RequestOption
-	params     url.Values
-	header     http.Header
-	bodyString string
-	fullURL    string
-	signErr    error
+// Request is a http request built by RequestOption, it is sent by GenericHttpClient
+type Request struct {
+	method      string
+	endpoint    string
+	private     bool
+	timestamp   bool
+	weight      int64
+	orderCount  int64
+	retry       *RetryPolicy
+	retrySafe   bool
+	signer      Signer
+	inBody      bool
+	query       url.Values
+	params      url.Values
+	header      http.Header
+	queryString string
+	bodyString  string
+	fullURL     string
 }
 
-type RequestOption func(req *reqMsg)
+type RequestOption func(req *Request)
+
+// Get the http method
+func (req *Request) Method() string {
+	return req.method
+}
+
+// Get the endpoint without the protocol, e.g. dapi.binance.com/dapi/v1/order
+func (req *Request) Endpoint() string {
+	return req.endpoint
+}
+
+// Get a copy of the parameters
+func (req *Request) Params() url.Values {
+	return cloneValues(req.params)
+}
+
+// Get a copy of the parameters which are always sent in the URL query
+func (req *Request) Query() url.Values {
+	return cloneValues(req.query)
+}
+
+// Get a copy of the headers
+func (req *Request) Header() http.Header {
+	return req.header.Clone()
+}
+
+// Check whether the request is sent with the API key
+func (req *Request) IsPrivate() bool {
+	return req.private
+}
+
+// Get the request weight
+func (req *Request) Weight() int64 {
+	return req.weight
+}
+
+// Get a deep copy of the request, the signer is shared
+func (req *Request) Clone() *Request {
+	clone := *req
+	clone.query = cloneValues(req.query)
+	clone.params = cloneValues(req.params)
+	clone.header = req.header.Clone()
+	if req.retry != nil {
+		retry := *req.retry
+		clone.retry = &retry
+	}
+	return &clone
+}
+
+func cloneValues(values url.Values) url.Values {
+	if values == nil {
+		return nil
+	}
+	out := make(url.Values, len(values))
+	for key, vals := range values {
+		out[key] = append([]string(nil), vals...)
+	}
+	return out
+}
 
 type HttpParameter struct {
 	Key        string
@@ -36,7 +101,7 @@ type HttpParameter struct {
 }
 
 func SetHeader(headers ...*HttpParameter) RequestOption {
-	return func(req *reqMsg) {
+	return func(req *Request) {
 		if req.header == nil {
 			req.header = http.Header{}
 		}
@@ -53,7 +118,7 @@ func SetHeader(headers ...*HttpParameter) RequestOption {
 }
 
 func SetMethod(method string) RequestOption {
-	return func(req *reqMsg) {
+	return func(req *Request) {
 		switch strings.ToUpper(method) {
 		case "PUT":
 			req.method = http.MethodPut
@@ -70,7 +135,7 @@ func SetMethod(method string) RequestOption {
 }
 
 func SetParams(params ...*HttpParameter) RequestOption {
-	return func(req *reqMsg) {
+	return func(req *Request) {
 		if req.params == nil {
 			req.params = url.Values{}
 		}
@@ -87,40 +152,115 @@ func SetParams(params ...*HttpParameter) RequestOption {
 }
 
 func SetEndpoint(endpoint string) RequestOption {
-	return func(req *reqMsg) {
+	return func(req *Request) {
 		req.endpoint = endpoint
 	}
 }
 
 func SetPrivate() RequestOption {
-	return func(req *reqMsg) {
+	return func(req *Request) {
 		req.private = true
 	}
 }
 
+// Stamp the request with the local time, the client replaces it with
+// the exchange's time when the server time synchronisation is enabled.
 func SetTimestamp() RequestOption {
-	return func(req *reqMsg) {
+	return func(req *Request) {
 		if req.params == nil {
 			req.params = url.Values{}
 		}
+		req.timestamp = true
 		req.params.Set(timestampKey, fmt.Sprintf("%d", time.Now().UnixMilli()))
 	}
 }
 
+// Sign the request with HMAC SHA256
 func SetSignature(secret string) RequestOption {
-	return func(r *reqMsg) {
-		r.signature = func(req *reqMsg) {
-			if req.params != nil {
-				bodyString := req.params.Encode()
-				mac := hmac.New(sha256.New, []byte(secret))
-				_, err := mac.Write([]byte(bodyString))
-				if err != nil {
-					req.signErr = err
-				} else {
-					signature := fmt.Sprintf("%x", mac.Sum(nil))
-					req.bodyString = fmt.Sprintf("%s&%s=%s", bodyString, signatureKey, signature)
-				}
+	return SetSigner(NewHMACSigner(secret))
+}
+
+// Sign the request with the signer, e.g. HMAC, Ed25519 or RSA
+func SetSigner(signer Signer) RequestOption {
+	return func(req *Request) {
+		req.signer = signer
+	}
+}
+
+// Set the parameters which are always sent in the URL query
+func SetQuery(params ...*HttpParameter) RequestOption {
+	return func(req *Request) {
+		if req.query == nil {
+			req.query = url.Values{}
+		}
+
+		for _, p := range params {
+			if p.NotReplace {
+				req.query.Add(p.Key, p.Val)
+			} else {
+				req.query.Set(p.Key, p.Val)
 			}
 		}
 	}
 }
+
+// Send the parameters of a non-GET request in the form-encoded body instead of the URL query
+func SetBodyParams() RequestOption {
+	return func(req *Request) {
+		req.inBody = true
+	}
+}
+
+// Encode the query string and the body, the signature is computed
+// over the query string concatenated with the body and appended to the latter.
+func (req *Request) encode(inBody bool) error {
+	query := req.query.Encode()
+	params := req.params.Encode()
+
+	if inBody {
+		req.queryString, req.bodyString = query, params
+	} else {
+		req.queryString, req.bodyString = joinQuery(query, params), ""
+	}
+
+	if req.signer == nil || req.params == nil {
+		return nil
+	}
+
+	signature, err := req.signer.Sign([]byte(req.queryString + req.bodyString))
+	if err != nil {
+		return err
+	}
+	signed := fmt.Sprintf("%s=%s", signatureKey, url.QueryEscape(signature))
+	if inBody {
+		req.bodyString = joinQuery(req.bodyString, signed)
+	} else {
+		req.queryString = joinQuery(req.queryString, signed)
+	}
+	return nil
+}
+
+func joinQuery(a, b string) string {
+	switch {
+	case a == "":
+		return b
+	case b == "":
+		return a
+	default:
+		return a + "&" + b
+	}
+}
+
+// Set the request weight checked against the rate limit budget, the default is 1
+func SetWeight(weight int64) RequestOption {
+	return func(req *Request) {
+		req.weight = weight
+	}
+}
+
+// Set the number of orders the request places, checked against the order count budget
+func SetOrderCount(count int64) RequestOption {
+	return func(req *Request) {
+		req.orderCount = count
+	}
+}
@@ -1,20 +1,39 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 )
 
 type GenericHttpSvcClient struct {
-	client   HTTPClient
-	protocol string
-	apiKey   string
+	client       HTTPClient
+	protocol     string
+	apiKey       string
+	clock        *serverClock
+	rateLimit    *rateLimitTracker
+	retry        *RetryPolicy
+	bodyParams   bool
+	interceptors []Interceptor
+	limiter      *Limiter
+	recvWindow   time.Duration
+	timeout      time.Duration
+
+	deadlineRecvWindow bool
 }
 
-func NewGenericHttpClient(apiKey string, useSSL bool, c HTTPClient) GenericHttpClient {
+type ClientOption func(c *GenericHttpSvcClient)
+
+func NewGenericHttpClient(apiKey string, useSSL bool, c HTTPClient, opts ...ClientOption) GenericHttpClient {
 	client := &GenericHttpSvcClient{
-		apiKey: apiKey,
+		apiKey:    apiKey,
+		rateLimit: newRateLimitTracker(),
 	}
 
 	if c == nil {
@@ -29,36 +48,163 @@ func NewGenericHttpClient(apiKey string, useSSL bool, c HTTPClient) GenericHttpC
 		client.protocol = "http"
 	}
 
+	for _, opt := range opts {
+		opt(client)
+	}
+
 	return client
 }
 
-// Execute a http request
-func (c *GenericHttpSvcClient) ExecuteHttpOperation(ctx context.Context, request *reqMsg) (*http.Response, error) {
+// Send the parameters of non-GET requests in the form-encoded body instead of the URL query
+func WithBodyParams() ClientOption {
+	return func(c *GenericHttpSvcClient) {
+		c.bodyParams = true
+	}
+}
+
+// Bound every request including its retries by the timeout,
+// the body of the response is read before the timeout is released
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *GenericHttpSvcClient) {
+		c.timeout = d
+	}
+}
+
+// Execute a http request through the interceptors.
+// A response with a status code of 400 or above is returned without an error and its body is readable,
+// the interceptors see it with an APIError.
+func (c *GenericHttpSvcClient) ExecuteHttpOperation(ctx context.Context, request *Request) (*http.Response, error) {
+	if c.timeout <= 0 {
+		return failedResponse(chain(c.interceptors, c.send)(ctx, request))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	resp, err := failedResponse(chain(c.interceptors, c.send)(ctx, request))
+	if err != nil || resp == nil || resp.Body == nil {
+		return resp, err
+	}
+
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// Return a failed response without the APIError given to the interceptors
+func failedResponse(resp *http.Response, err error) (*http.Response, error) {
+	var apiErr *APIError
+	if resp != nil && errors.As(err, &apiErr) {
+		return resp, nil
+	}
+	return resp, err
+}
+
+// Send a http request with the retry policy, a failed response is returned with an APIError
+// and its body is kept readable
+func (c *GenericHttpSvcClient) send(ctx context.Context, request *Request) (*http.Response, error) {
+	resp, err := c.retryExecute(ctx, request)
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+
+	var body []byte
+	if resp.Body != nil {
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	apiErr := NewAPIError(&http.Response{StatusCode: resp.StatusCode, Body: ioutil.NopCloser(bytes.NewReader(body))}, request.endpoint)
+	return resp, apiErr
+}
+
+func (c *GenericHttpSvcClient) retryExecute(ctx context.Context, request *Request) (*http.Response, error) {
+	policy := request.retry
+	if policy == nil {
+		policy = c.retry
+	}
+	if policy == nil || (!isIdempotent(request.method) && !request.retrySafe) {
+		return c.execute(ctx, request)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.execute(ctx, request)
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay, retry := policy.backoff(attempt, resp, err)
+		if !retry {
+			return resp, err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+			return resp, err
+		}
+
+		discard(resp)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Execute a single attempt of a http request
+func (c *GenericHttpSvcClient) execute(ctx context.Context, request *Request) (*http.Response, error) {
+
+	// Wait for the budget and the limiter before the request is stamped and signed, so the timestamp is not stale
+	if err := c.rateLimit.allow(ctx, request.weight, request.orderCount); err != nil {
+		return nil, err
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx, request.weight); err != nil {
+			return nil, err
+		}
+	}
 
 	if request.private {
 		SetHeader(&HttpParameter{Key: "X-MBX-APIKEY", Val: c.apiKey})(request)
 	}
 
+	if request.timestamp {
+		if c.clock != nil {
+			request.params.Set(timestampKey, fmt.Sprintf("%d", c.clock.now(ctx, c)))
+		} else {
+			request.params.Set(timestampKey, fmt.Sprintf("%d", time.Now().UnixMilli()))
+		}
+		if err := c.applyRecvWindow(ctx, request); err != nil {
+			return nil, err
+		}
+	}
+
 	if request.params != nil {
 		SetHeader(&HttpParameter{Key: "Content-Type", Val: "application/x-www-form-urlencoded"})(request)
 	}
 
-	if request.signature != nil {
-		request.signature(request)
-	} else {
-		request.bodyString = request.params.Encode()
-	}
-	if request.signErr != nil {
-		return nil, request.signErr
+	inBody := (request.inBody || c.bodyParams) && request.method != http.MethodGet
+	if err := request.encode(inBody); err != nil {
+		return nil, err
 	}
 
-	if request.bodyString != "" {
-		request.fullURL = fmt.Sprintf("%s://%s?%s", c.protocol, request.endpoint, request.bodyString)
+	if request.queryString != "" {
+		request.fullURL = fmt.Sprintf("%s://%s?%s", c.protocol, request.endpoint, request.queryString)
 	} else {
 		request.fullURL = fmt.Sprintf("%s://%s", c.protocol, request.endpoint)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, request.method, request.fullURL, nil)
+	var body io.Reader
+	if request.bodyString != "" {
+		body = strings.NewReader(request.bodyString)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, request.method, request.fullURL, body)
 
 	if err != nil {
 		return nil, err
@@ -68,19 +214,32 @@ func (c *GenericHttpSvcClient) ExecuteHttpOperation(ctx context.Context, request
 		req.Header = request.header
 	}
 
+	// A context which is done before the request is sent is returned as is, outside of a url.Error,
+	// so the caller can tell that the request never reached the exchange
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	resp, err := c.client.Do(req)
 
 	if err != nil {
 		return nil, err
 	}
 
+	c.rateLimit.record(resp.Header)
+
+	if request.timestamp && c.clock != nil && isInvalidTimestamp(resp) {
+		c.clock.invalidate()
+	}
+
 	return resp, nil
 }
 
 // Create a http request
-func (c *GenericHttpSvcClient) GetHttpRequest(opts ...RequestOption) *reqMsg {
-	req := &reqMsg{
+func (c *GenericHttpSvcClient) GetHttpRequest(opts ...RequestOption) *Request {
+	req := &Request{
 		private: false,
+		weight:  1,
 	}
 
 	for _, opt := range opts {
@@ -89,3 +248,8 @@ func (c *GenericHttpSvcClient) GetHttpRequest(opts ...RequestOption) *reqMsg {
 
 	return req
 }
+
+// Get the rate limit usage reported by the latest responses
+func (c *GenericHttpSvcClient) GetRateLimitUsage() RateLimitUsage {
+	return c.rateLimit.usage()
+}
@@ -0,0 +1,31 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+)
+
+// Handler sends a request and returns its outcome, a failed response is returned with an APIError
+type Handler func(ctx context.Context, request *Request) (*http.Response, error)
+
+// Interceptor wraps the sending of a request, it calls next to continue the chain
+// and may modify the request or the context before, or inspect the outcome after.
+type Interceptor func(ctx context.Context, request *Request, next Handler) (*http.Response, error)
+
+// Add interceptors to the client, the first one is the outermost one.
+// An interceptor sees one call per request, retries happen inside the chain.
+func WithInterceptors(interceptors ...Interceptor) ClientOption {
+	return func(c *GenericHttpSvcClient) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+func chain(interceptors []Interceptor, handler Handler) Handler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, request *Request) (*http.Response, error) {
+			return interceptor(ctx, request, next)
+		}
+	}
+	return handler
+}
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/h9896/bingo/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type traceKey struct{}
+
+func TestInterceptors(t *testing.T) {
+	calls := []string{}
+	var outcome error
+
+	logging := func(ctx context.Context, request *Request, next Handler) (*http.Response, error) {
+		calls = append(calls, "logging:"+request.Endpoint())
+		resp, err := next(ctx, request)
+		outcome = err
+		calls = append(calls, "logging:done")
+		return resp, err
+	}
+	tracing := func(ctx context.Context, request *Request, next Handler) (*http.Response, error) {
+		calls = append(calls, "tracing")
+		SetHeader(&HttpParameter{Key: "X-Trace-Id", Val: ctx.Value(traceKey{}).(string)})(request)
+		return next(ctx, request)
+	}
+
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{}, WithInterceptors(logging, tracing))
+
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		calls = append(calls, "send")
+		assert.EqualValues(t, "trace-1", req.Header.Get("X-Trace-Id"))
+		assert.EqualValues(t, "apikey", req.Header.Get("X-MBX-APIKEY"))
+		data := `{"code": -2011, "msg": "Unknown order sent."}`
+		resp = &http.Response{StatusCode: 400, Body: ioutil.NopCloser(bytes.NewBufferString(data))}
+		return
+	}
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "trace-1")
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("delete"), SetPrivate())
+	resp, err := client.ExecuteHttpOperation(ctx, req)
+
+	assert.EqualValues(t, []string{"logging:dapi.binance.com/dapi/v1/order", "tracing", "send", "logging:done"}, calls)
+
+	// The interceptors see the decoded outcome
+	apiErr := &APIError{}
+	assert.True(t, errors.As(outcome, &apiErr))
+	assert.EqualValues(t, -2011, apiErr.Code)
+
+	// The caller gets the failed response with its body
+	assert.Nil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+	assert.EqualValues(t, -2011, NewAPIError(resp, req.Endpoint()).Code)
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	denied := errors.New("denied")
+	audit := func(ctx context.Context, request *Request, next Handler) (*http.Response, error) {
+		if request.Method() != http.MethodGet {
+			return nil, denied
+		}
+		return next(ctx, request)
+	}
+
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{}, WithInterceptors(audit))
+
+	sent := 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		sent++
+		resp = &http.Response{StatusCode: 200, Request: req}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("post"))
+	_, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.ErrorIs(t, err, denied)
+	assert.EqualValues(t, 0, sent)
+
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("get"))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, sent)
+}
@@ -0,0 +1,101 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket consumed by the weight of the requests
+type Limiter struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	rate     float64
+	last     time.Time
+}
+
+// Create a token bucket which holds up to capacity tokens and refills capacity tokens
+// per interval, e.g. NewLimiter(2400, time.Minute) for the request weight limit of COIN-M futures
+func NewLimiter(capacity int64, interval time.Duration) *Limiter {
+	return &Limiter{
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		rate:     float64(capacity) / interval.Seconds(),
+		last:     time.Now(),
+	}
+}
+
+// Wait for the tokens of the request weight before sending a request
+func WithLimiter(limiter *Limiter) ClientOption {
+	return func(c *GenericHttpSvcClient) {
+		c.limiter = limiter
+	}
+}
+
+// Take n tokens without waiting, it reports whether the tokens are taken
+func (l *Limiter) Allow(n int64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	if l.tokens < float64(n) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
+
+// Wait until n tokens are available and take them.
+// The tokens are reserved first, so the waiting requests are served in order.
+func (l *Limiter) Wait(ctx context.Context, n int64) error {
+	if float64(n) > l.capacity {
+		return fmt.Errorf("request weight %d exceeds the limiter capacity %v", n, l.capacity)
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait == 0 {
+		return nil
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		l.cancel(n)
+		return fmt.Errorf("waiting %v for request weight %d exceeds the deadline: %w", wait, n, context.DeadlineExceeded)
+	}
+
+	if err := sleep(ctx, wait); err != nil {
+		l.cancel(n)
+		return err
+	}
+	return nil
+}
+
+// Give back the tokens of a cancelled reservation
+func (l *Limiter) cancel(n int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens += float64(n)
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+}
+
+func (l *Limiter) refill(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens += elapsed.Seconds() * l.rate
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+		l.last = now
+	}
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/h9896/bingo/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter(10, 100*time.Millisecond)
+
+	assert.True(t, limiter.Allow(6))
+	assert.False(t, limiter.Allow(6))
+
+	// 6 tokens are refilled in 60ms
+	start := time.Now()
+	assert.Nil(t, limiter.Wait(context.Background(), 10))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// A cancelled reservation gives back the tokens
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx, 10), context.DeadlineExceeded)
+	assert.NotNil(t, limiter.Wait(context.Background(), 11))
+}
+
+func TestExecuteHttpOperationWithLimiter(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{}, WithLimiter(NewLimiter(40, time.Hour)))
+
+	sent := 0
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		sent++
+		resp = &http.Response{StatusCode: 200, Request: req}
+		return
+	}
+
+	for i := 0; i < 2; i++ {
+		req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/allOrders"), SetMethod("get"), SetWeight(20))
+		_, err := client.ExecuteHttpOperation(context.Background(), req)
+		assert.Nil(t, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/allOrders"), SetMethod("get"), SetWeight(20))
+	_, err := client.ExecuteHttpOperation(ctx, req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.EqualValues(t, 2, sent)
+}
+
+func TestExecuteHttpOperationWithLimiterTimestamp(t *testing.T) {
+	client := NewGenericHttpClient("apikey", false, &mocks.MockHTTPClient{}, WithLimiter(NewLimiter(1, 100*time.Millisecond)))
+
+	var timestamp int64
+	mocks.GetDoFunc = func(req *http.Request) (resp *http.Response, err error) {
+		timestamp, _ = strconv.ParseInt(req.URL.Query().Get(timestampKey), 10, 64)
+		resp = &http.Response{StatusCode: 200, Request: req}
+		return
+	}
+
+	req := client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("get"), SetTimestamp(), SetSignature("secret"))
+	_, err := client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+
+	// The throttled request is stamped after the wait
+	start := time.Now()
+	req = client.GetHttpRequest(SetEndpoint("dapi.binance.com/dapi/v1/order"), SetMethod("get"), SetTimestamp(), SetSignature("secret"))
+	_, err = client.ExecuteHttpOperation(context.Background(), req)
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, timestamp, start.Add(80*time.Millisecond).UnixMilli())
+}
@@ -0,0 +1,212 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	usedWeightHeader = "X-MBX-USED-WEIGHT-"
+	orderCountHeader = "X-MBX-ORDER-COUNT-"
+)
+
+var ErrRateLimitExceeded = errors.New("rate limit budget exceeded")
+
+// RateLimitUsage is the usage reported by the exchange, keyed by interval, e.g. "1M"
+type RateLimitUsage struct {
+	UsedWeight map[string]int64
+	OrderCount map[string]int64
+	UpdatedAt  time.Time
+}
+
+// RateLimitBudget is the maximum usage allowed in an interval, e.g. "1M".
+// A zero MaxWeight or MaxOrders means no budget on it.
+// With Wait, a request exceeding the budget blocks until the next interval instead of failing.
+type RateLimitBudget struct {
+	Interval  string
+	MaxWeight int64
+	MaxOrders int64
+	Wait      bool
+}
+
+type usage struct {
+	value  int64
+	window time.Time
+}
+
+type rateLimitTracker struct {
+	mu         sync.Mutex
+	usedWeight map[string]usage
+	orderCount map[string]usage
+	updatedAt  time.Time
+	budgets    []budget
+}
+
+// A budget with its interval parsed
+type budget struct {
+	RateLimitBudget
+	length time.Duration
+}
+
+// Check the recorded usage against the budgets before sending a request.
+// It panics if the interval of a budget is invalid.
+func WithRateLimitBudget(budgets ...RateLimitBudget) ClientOption {
+	parsed := make([]budget, 0, len(budgets))
+	for _, b := range budgets {
+		b.Interval = strings.ToUpper(b.Interval)
+		d, err := parseInterval(b.Interval)
+		if err != nil {
+			panic(fmt.Sprintf("rpc: %v", err))
+		}
+		parsed = append(parsed, budget{RateLimitBudget: b, length: d})
+	}
+	return func(c *GenericHttpSvcClient) {
+		c.rateLimit.budgets = append(c.rateLimit.budgets, parsed...)
+	}
+}
+
+func newRateLimitTracker() *rateLimitTracker {
+	return &rateLimitTracker{
+		usedWeight: map[string]usage{},
+		orderCount: map[string]usage{},
+	}
+}
+
+// Record the X-MBX-USED-WEIGHT-* and X-MBX-ORDER-COUNT-* headers of a response
+func (r *rateLimitTracker) record(header http.Header) {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, vals := range header {
+		if len(vals) == 0 {
+			continue
+		}
+		key = strings.ToUpper(key)
+
+		var records map[string]usage
+		var interval string
+		switch {
+		case strings.HasPrefix(key, usedWeightHeader):
+			records, interval = r.usedWeight, strings.TrimPrefix(key, usedWeightHeader)
+		case strings.HasPrefix(key, orderCountHeader):
+			records, interval = r.orderCount, strings.TrimPrefix(key, orderCountHeader)
+		default:
+			continue
+		}
+
+		d, err := parseInterval(interval)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseInt(vals[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		records[interval] = usage{value: value, window: now.Truncate(d)}
+		r.updatedAt = now
+	}
+}
+
+func (r *rateLimitTracker) usage() RateLimitUsage {
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := RateLimitUsage{
+		UsedWeight: map[string]int64{},
+		OrderCount: map[string]int64{},
+		UpdatedAt:  r.updatedAt,
+	}
+	for interval := range r.usedWeight {
+		out.UsedWeight[interval] = current(r.usedWeight, interval, now)
+	}
+	for interval := range r.orderCount {
+		out.OrderCount[interval] = current(r.orderCount, interval, now)
+	}
+	return out
+}
+
+// Wait or fail when the request would exceed a budget
+func (r *rateLimitTracker) allow(ctx context.Context, weight, orders int64) error {
+	for _, b := range r.budgets {
+		interval, d := b.Interval, b.length
+		for {
+			now := time.Now()
+
+			r.mu.Lock()
+			usedWeight := current(r.usedWeight, interval, now)
+			orderCount := current(r.orderCount, interval, now)
+			r.mu.Unlock()
+
+			var exceeded error
+			if b.MaxWeight > 0 && usedWeight+weight > b.MaxWeight {
+				exceeded = fmt.Errorf("%w: used weight %d + %d > %d in %s", ErrRateLimitExceeded, usedWeight, weight, b.MaxWeight, interval)
+			} else if b.MaxOrders > 0 && orders > 0 && orderCount+orders > b.MaxOrders {
+				exceeded = fmt.Errorf("%w: order count %d + %d > %d in %s", ErrRateLimitExceeded, orderCount, orders, b.MaxOrders, interval)
+			}
+
+			if exceeded == nil {
+				break
+			}
+			if !b.Wait {
+				return exceeded
+			}
+
+			timer := time.NewTimer(now.Truncate(d).Add(d).Sub(now))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+	return nil
+}
+
+// Get the usage of an interval, a usage recorded in a previous window is reset
+func current(records map[string]usage, interval string, now time.Time) int64 {
+	u, ok := records[interval]
+	if !ok {
+		return 0
+	}
+	d, err := parseInterval(interval)
+	if err != nil || !u.window.Equal(now.Truncate(d)) {
+		return 0
+	}
+	return u.value
+}
+
+// Parse the interval of binance, e.g. 1S, 1M, 1H, 1D
+func parseInterval(interval string) (time.Duration, error) {
+	if len(interval) < 2 {
+		return 0, fmt.Errorf("invalid rate limit interval: %q", interval)
+	}
+
+	num, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || num <= 0 {
+		return 0, fmt.Errorf("invalid rate limit interval: %q", interval)
+	}
+
+	switch strings.ToUpper(interval[len(interval)-1:]) {
+	case "S":
+		return time.Duration(num) * time.Second, nil
+	case "M":
+		return time.Duration(num) * time.Minute, nil
+	case "H":
+		return time.Duration(num) * time.Hour, nil
+	case "D":
+		return time.Duration(num) * 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("invalid rate limit interval: %q", interval)
+	}
+}
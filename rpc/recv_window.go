@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const recvWindowKey = "recvWindow"
+
+// The maximum recvWindow accepted by the exchange
+const MaxRecvWindow = 60 * time.Second
+
+// Send a recvWindow with the timestamped requests which do not set one
+func WithRecvWindow(d time.Duration) ClientOption {
+	return func(c *GenericHttpSvcClient) {
+		c.recvWindow = d
+	}
+}
+
+// Limit the recvWindow of the timestamped requests to the time left before the deadline of the context,
+// so the exchange rejects a request which arrives after the caller has given up
+func WithRecvWindowFromDeadline() ClientOption {
+	return func(c *GenericHttpSvcClient) {
+		c.deadlineRecvWindow = true
+	}
+}
+
+// Set the recvWindow of a timestamped request
+func (c *GenericHttpSvcClient) applyRecvWindow(ctx context.Context, request *Request) error {
+	recvWindow, changed := c.recvWindow, true
+	if val := request.params.Get(recvWindowKey); val != "" {
+		ms, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid recvWindow %q: %w", val, err)
+		}
+		recvWindow, changed = time.Duration(ms)*time.Millisecond, false
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && c.deadlineRecvWindow {
+		left := time.Until(deadline).Truncate(time.Millisecond)
+		if left <= 0 {
+			return fmt.Errorf("no time left for recvWindow: %w", context.DeadlineExceeded)
+		}
+		if left > MaxRecvWindow {
+			left = MaxRecvWindow
+		}
+		if recvWindow <= 0 || left < recvWindow {
+			recvWindow, changed = left, true
+		}
+	}
+
+	if changed && recvWindow > 0 {
+		request.params.Set(recvWindowKey, strconv.FormatInt(recvWindow.Milliseconds(), 10))
+	}
+	return nil
+}
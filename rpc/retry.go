@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy retries a request on network errors, 5xx, 429 and 418 responses.
+// The errors of the client itself, e.g. a context, rate limit or signing error, are not retried.
+// It is only applied to idempotent methods or to requests marked by SetRetrySafe.
+type RetryPolicy struct {
+	// Maximum number of attempts including the first one
+	MaxAttempts int
+	// Delay before the first retry, doubled on every retry
+	BaseDelay time.Duration
+	// Upper bound of the delay
+	MaxDelay time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// Retry the requests of the client with the policy
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *GenericHttpSvcClient) {
+		c.retry = &policy
+	}
+}
+
+// Retry the request with the policy instead of the one of the client
+func SetRetryPolicy(policy RetryPolicy) RequestOption {
+	return func(req *Request) {
+		req.retry = &policy
+	}
+}
+
+// Mark a non-idempotent request as safe to retry
+func SetRetrySafe() RequestOption {
+	return func(req *Request) {
+		req.retrySafe = true
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Get the delay before the next attempt, or false if the outcome is not retryable
+func (p *RetryPolicy) backoff(attempt int, resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		if IsTransportError(err) {
+			return p.jitter(attempt), true
+		}
+		return 0, false
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot:
+		if after, ok := retryAfter(resp); ok {
+			return after, true
+		}
+		return p.jitter(attempt), true
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return p.jitter(attempt), true
+	}
+	return 0, false
+}
+
+// IsTransportError checks whether an error is raised by the network while the request is sent:
+// a failed connection, read or write, an unexpected EOF, a reset or a timeout of the transport.
+// A cancelled or expired context is not, neither are the other errors of http.Client wrapped in *url.Error,
+// e.g. an invalid certificate or too many redirects.
+func IsTransportError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	// *url.Error is a net.Error itself, it only reports the timeout of the error it wraps
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Exponential backoff with full jitter
+func (p *RetryPolicy) jitter(attempt int) time.Duration {
+	delay := p.BaseDelay << uint(attempt-1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(delay)))
+}
+
+// Parse the Retry-After header in seconds
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	val := resp.Header.Get("Retry-After")
+	if val == "" {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(val)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Drain and close the body of a response which is going to be retried
+func discard(resp *http.Response) {
+	if resp != nil && resp.Body != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
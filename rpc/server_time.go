@@ -0,0 +1,169 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	codeInvalidTimestamp = -1021
+
+	// The delay before the first retry of a failed refresh, it doubles up to the interval
+	serverTimeRetryDelay = time.Second
+)
+
+// serverClock keeps the offset between the local clock and the exchange's clock
+type serverClock struct {
+	endpoint string
+	interval time.Duration
+
+	mu       sync.Mutex
+	offset   time.Duration
+	syncedAt time.Time
+	// Whether an offset has been fetched at least once
+	known bool
+	// Closed when the refresh in flight completes, nil when there is none
+	syncing chan struct{}
+	// No refresh is attempted before retryAt after a failure
+	failures int
+	retryAt  time.Time
+}
+
+type serverTime struct {
+	ServerTime int64 `json:"serverTime"`
+}
+
+// Enable the synchronisation of the timestamp with the exchange's time endpoint,
+// e.g. "dapi.binance.com/dapi/v1/time". The offset is refreshed every interval
+// and whenever the exchange rejects a request with -1021. It panics if the interval is not positive.
+func WithServerTimeSync(endpoint string, interval time.Duration) ClientOption {
+	if interval <= 0 {
+		panic(fmt.Sprintf("rpc: non-positive interval %v for WithServerTimeSync", interval))
+	}
+	return func(c *GenericHttpSvcClient) {
+		c.clock = &serverClock{
+			endpoint: endpoint,
+			interval: interval,
+		}
+	}
+}
+
+// Get the current time of the exchange in milliseconds, the offset is refreshed when it is stale.
+// Only one refresh is in flight, the other requests use the last known offset meanwhile
+// and only wait for the refresh when there is none yet. If the refresh fails, the last known offset is used
+// and the refresh is retried with an exponential backoff, so a failing time endpoint is not called by every request.
+func (s *serverClock) now(ctx context.Context, c *GenericHttpSvcClient) int64 {
+	s.mu.Lock()
+	stale := (s.syncedAt.IsZero() || time.Since(s.syncedAt) >= s.interval) && !time.Now().Before(s.retryAt)
+	switch {
+	case stale && s.syncing == nil:
+		done := make(chan struct{})
+		s.syncing = done
+		s.mu.Unlock()
+
+		offset, err := s.fetchOffset(ctx, c)
+
+		s.mu.Lock()
+		if err == nil {
+			s.offset = offset
+			s.syncedAt = time.Now()
+			s.known = true
+			s.failures, s.retryAt = 0, time.Time{}
+		} else {
+			s.failures++
+			s.retryAt = time.Now().Add(s.retryDelay())
+		}
+		s.syncing = nil
+		close(done)
+	case stale && !s.known:
+		done := s.syncing
+		s.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+		s.mu.Lock()
+	}
+	offset := s.offset
+	s.mu.Unlock()
+
+	return time.Now().Add(offset).UnixMilli()
+}
+
+// The delay before the next refresh after the failures, from serverTimeRetryDelay up to the interval
+func (s *serverClock) retryDelay() time.Duration {
+	delay := serverTimeRetryDelay
+	for i := 1; i < s.failures && delay < s.interval; i++ {
+		delay *= 2
+	}
+	if delay > s.interval {
+		delay = s.interval
+	}
+	return delay
+}
+
+// Mark the offset as stale, the next timestamp will trigger a refresh
+func (s *serverClock) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.syncedAt = time.Time{}
+}
+
+func (s *serverClock) fetchOffset(ctx context.Context, c *GenericHttpSvcClient) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s", c.protocol, s.endpoint), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	end := time.Now()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, NewAPIError(resp, s.endpoint)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	out := &serverTime{}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return 0, err
+	}
+
+	// Assume the server stamped the time halfway through the round trip
+	local := start.Add(end.Sub(start) / 2)
+	return time.UnixMilli(out.ServerTime).Sub(local), nil
+}
+
+// Check whether a failed response is a -1021 error without consuming its body
+func isInvalidTimestamp(resp *http.Response) bool {
+	if resp.StatusCode < http.StatusBadRequest || resp.Body == nil {
+		return false
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	if err != nil {
+		return false
+	}
+
+	apiErr := &APIError{}
+	if err := json.Unmarshal(respBody, apiErr); err != nil {
+		return false
+	}
+	return apiErr.Code == codeInvalidTimestamp
+}
@@ -0,0 +1,117 @@
+package rpc
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Signer signs the payload of a request, the signature is URL-encoded by the request
+type Signer interface {
+	Sign(payload []byte) (string, error)
+}
+
+type hmacSigner struct {
+	secret []byte
+}
+
+// Sign with HMAC SHA256, the signature is hex encoded
+func NewHMACSigner(secret string) Signer {
+	return &hmacSigner{secret: []byte(secret)}
+}
+
+func (s *hmacSigner) Sign(payload []byte) (string, error) {
+	mac := hmac.New(sha256.New, s.secret)
+	if _, err := mac.Write(payload); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+// Sign with Ed25519, the signature is base64 encoded
+func NewEd25519Signer(key ed25519.PrivateKey) Signer {
+	return &ed25519Signer{key: key}
+}
+
+func (s *ed25519Signer) Sign(payload []byte) (string, error) {
+	if len(s.key) != ed25519.PrivateKeySize {
+		return "", errors.New("invalid ed25519 private key")
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payload)), nil
+}
+
+type rsaSigner struct {
+	key *rsa.PrivateKey
+}
+
+// Sign with RSA PKCS#1 v1.5 SHA256, the signature is base64 encoded
+func NewRSASigner(key *rsa.PrivateKey) Signer {
+	return &rsaSigner{key: key}
+}
+
+func (s *rsaSigner) Sign(payload []byte) (string, error) {
+	if s.key == nil {
+		return "", errors.New("invalid rsa private key")
+	}
+	hashed := sha256.Sum256(payload)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// Parse a PEM encoded PKCS#8 Ed25519 private key
+func ParseEd25519PrivateKey(pemBytes []byte) (ed25519.PrivateKey, error) {
+	key, err := parsePKCS8PrivateKey(pemBytes)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not an ed25519 private key: %T", key)
+	}
+	return edKey, nil
+}
+
+// Parse a PEM encoded PKCS#1 or PKCS#8 RSA private key
+func ParseRSAPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := parsePKCS8PrivateKey(pemBytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("not a rsa private key: %T", key)
+	}
+	return rsaKey, nil
+}
+
+func parsePKCS8PrivateKey(pemBytes []byte) (interface{}, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("failed to decode PEM block")
+	}
+	return x509.ParsePKCS8PrivateKey(block.Bytes)
+}
@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const payload = "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559"
+
+func TestHMACSigner(t *testing.T) {
+	// The example of the binance document
+	signer := NewHMACSigner("NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j")
+	signature, err := signer.Sign([]byte(payload))
+	assert.Nil(t, err)
+	assert.EqualValues(t, "c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71", signature)
+}
+
+func TestEd25519Signer(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	assert.Nil(t, err)
+	parsed, err := ParseEd25519PrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.Nil(t, err)
+
+	signature, err := NewEd25519Signer(parsed).Sign([]byte(payload))
+	assert.Nil(t, err)
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	assert.Nil(t, err)
+	assert.True(t, ed25519.Verify(pub, []byte(payload), raw))
+
+	_, err = NewEd25519Signer(nil).Sign([]byte(payload))
+	assert.NotNil(t, err)
+}
+
+func TestRSASigner(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	parsed, err := ParseRSAPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)}))
+	assert.Nil(t, err)
+
+	signature, err := NewRSASigner(parsed).Sign([]byte(payload))
+	assert.Nil(t, err)
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	assert.Nil(t, err)
+	hashed := sha256.Sum256([]byte(payload))
+	assert.Nil(t, rsa.VerifyPKCS1v15(&priv.PublicKey, crypto.SHA256, hashed[:], raw))
+
+	_, err = ParseRSAPrivateKey([]byte("not a key"))
+	assert.NotNil(t, err)
+}
+
+func TestSetSignerEncodesSignature(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	signer := NewEd25519Signer(priv)
+
+	req := &Request{}
+	SetParams(&HttpParameter{Key: "symbol", Val: "BTCUSD_PERP"})(req)
+	SetSigner(signer)(req)
+	assert.Nil(t, req.encode(false))
+
+	// The base64 signature survives the URL encoding
+	query, err := url.ParseQuery(req.queryString)
+	assert.Nil(t, err)
+	expect, _ := signer.Sign([]byte("symbol=BTCUSD_PERP"))
+	assert.EqualValues(t, expect, query.Get(signatureKey))
+}
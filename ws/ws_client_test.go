@@ -1,6 +1,8 @@
 package ws
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -12,11 +14,13 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/h9896/bingo/events"
+	"github.com/h9896/bingo/profile"
 	"github.com/stretchr/testify/assert"
 )
 
 var upgrader = websocket.Upgrader{}
 
+// Acknowledge every request and send the data after it
 func echo(data []byte) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		c, err := upgrader.Upgrade(w, r, nil)
@@ -25,7 +29,15 @@ func echo(data []byte) func(w http.ResponseWriter, r *http.Request) {
 		}
 		defer c.Close()
 		for {
-			mt, _, err := c.ReadMessage()
+			mt, message, err := c.ReadMessage()
+			if err != nil {
+				break
+			}
+			req := &SubReq{}
+			if err := json.Unmarshal(message, req); err != nil {
+				break
+			}
+			err = c.WriteMessage(mt, []byte(fmt.Sprintf(`{"result":null,"id":%d}`, req.Id)))
 			if err != nil {
 				break
 			}
@@ -50,20 +62,100 @@ func TestWsClient(t *testing.T) {
 	defer s.Close()
 
 	cfg := WsConfig{
-		Name: s.URL,
+		Name:    s.URL,
+		Symbols: []string{"btcusd_perp"},
+		Service: "aggTrade",
 	}
 
-	client := newTestClient(t)
+	// The acknowledgement is not passed to MsgHandler
+	client := &countClient{testClient: testClient{aggregate: &events.AggregateMsg{}, t: t}}
 	cleanup, err := StartSubscribe(client, cfg)
-	if err != nil {
-		log.Println(err)
-		cleanup()
-	}
-	time.Sleep(2 * time.Second)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return client.count() == 1
+	}, 2*time.Second, time.Millisecond)
+
+	cleanup()
+}
 
+func TestStartSubscribeRejected(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(subscriptions(nil)))
+	defer s.Close()
+
+	cfg := WsConfig{Name: s.URL, Symbols: []string{"invalid"}, Service: "aggTrade"}
+	cleanup, err := StartSubscribe(newTestClient(t), cfg)
+	subErr := &SubscriptionError{}
+	assert.True(t, errors.As(err, &subErr))
+	assert.EqualValues(t, "Invalid request: unknown stream", subErr.Message)
 	cleanup()
 }
 
+// Calls cleanup from MsgHandler or ErrHandler once it is ready
+type cleanupClient struct {
+	testClient
+	onMsg    bool
+	cleanup  func()
+	ready    chan struct{}
+	returned chan struct{}
+	once     sync.Once
+}
+
+func (c *cleanupClient) MsgHandler(msg []byte) {
+	if c.onMsg {
+		c.close()
+	}
+}
+
+func (c *cleanupClient) ErrHandler(err error) {
+	if !c.onMsg {
+		c.close()
+	}
+}
+
+func (c *cleanupClient) close() {
+	c.once.Do(func() {
+		<-c.ready
+		c.cleanup()
+		close(c.returned)
+	})
+}
+
+func TestCleanupFromHandler(t *testing.T) {
+	data := []byte(`{"e": "aggTrade", "s": "BTCUSD_PERP", "p": "30000.2", "q": "234"}`)
+	starts := map[string]func(client WsClient, cfg WsConfig) (func(), error){
+		"subscribe": StartSubscribe[WsClient],
+		"reconnecting": func(client WsClient, cfg WsConfig) (func(), error) {
+			return StartReconnectingSubscribe(client, cfg)
+		},
+	}
+
+	for name, start := range starts {
+		for _, onMsg := range []bool{true, false} {
+			// The connection is dropped after the subscription to reach ErrHandler
+			s := httptest.NewServer(http.HandlerFunc(dropAfter(1, data, make(chan *SubReq, 1))))
+			client := &cleanupClient{onMsg: onMsg, ready: make(chan struct{}), returned: make(chan struct{})}
+			cfg := WsConfig{Name: s.URL, Symbols: []string{"btcusd_perp"}, Service: "aggTrade"}
+			cleanup, err := start(client, cfg)
+			assert.Nil(t, err)
+			client.cleanup = cleanup
+			close(client.ready)
+
+			select {
+			case <-client.returned:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("%s: cleanup called from the handler (onMsg=%v) does not return", name, onMsg)
+			}
+			cleanup()
+			s.Close()
+		}
+	}
+}
+
+func TestRequestIds(t *testing.T) {
+	first := nextRequestId()
+	assert.EqualValues(t, first+1, nextRequestId())
+}
+
 type testClient struct {
 	aggregate *events.AggregateMsg
 	t         *testing.T
@@ -100,3 +192,19 @@ func (c *testClient) MsgHandler(msg []byte) {
 	assert.EqualValues(c.t, "30000.2", c.aggregate.Price)
 	assert.EqualValues(c.t, "234", c.aggregate.Quantity)
 }
+
+func TestWsConfigFromProfile(t *testing.T) {
+	cfg := NewWsConfig(profile.Testnet.CoinM, "CoinM", []string{"btcusd_perp"}, "aggTrade")
+	assert.True(t, cfg.UseSSL)
+	assert.EqualValues(t, "wss://dstream.binancefuture.com/ws", cfg.GetBaseEndpoint())
+
+	cfg = NewWsConfig(profile.Production.Spot, "Spot", []string{"btcusdt"}, "aggTrade")
+	assert.EqualValues(t, "wss://stream.binance.com:9443/ws", cfg.GetBaseEndpoint())
+
+	cfg = NewWsConfig(profile.Custom("mock", profile.Endpoints{WsBaseURL: "127.0.0.1:8080"}, profile.Endpoints{}, profile.Endpoints{}).CoinM, "CoinM", nil, "aggTrade")
+	assert.EqualValues(t, "ws://127.0.0.1:8080/ws", cfg.GetBaseEndpoint())
+
+	// Without a profile, the COIN-M futures streams are used
+	cfg = WsConfig{UseSSL: false}
+	assert.EqualValues(t, "ws://dstream.binance.com/ws", cfg.GetBaseEndpoint())
+}
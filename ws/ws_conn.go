@@ -0,0 +1,268 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// The time given to the server to respond to a request
+const DefaultResponseTimeout = 10 * time.Second
+
+var ErrConnClosed = errors.New("ws connection is closed")
+
+// The ids of the requests increase monotonically across the connections
+var requestId int64
+
+func nextRequestId() int64 {
+	return atomic.AddInt64(&requestId, 1)
+}
+
+// Conn is a connection whose streams are subscribed and unsubscribed while it is open.
+// The responses of the requests are matched by their ids and not passed to MsgHandler.
+type Conn struct {
+	conn    *websocket.Conn
+	handler WsClient
+	timeout time.Duration
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	streams []string
+	pending map[int64]chan *SubResp
+	closing bool
+	// Set while the read goroutine runs a handler, Close does not wait for it then
+	handling bool
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Open a connection and subscribe the streams of the config,
+// the connection is closed and the error is returned when the subscription is rejected
+func Connect[T WsClient](client T, cfg WsConfig) (*Conn, error) {
+	return connect(client, client.GetEndpoint(cfg), client.GetServices(cfg))
+}
+
+// Open a connection to the endpoint and subscribe the streams
+func connect(client WsClient, endpoint string, streams []string) (*Conn, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{
+		conn:    conn,
+		handler: client,
+		timeout: DefaultResponseTimeout,
+		pending: map[int64]chan *SubResp{},
+		done:    make(chan struct{}),
+	}
+	conn.SetPingHandler(
+		func(appData string) error {
+			return c.write(websocket.PongMessage, []byte(appData))
+		},
+	)
+	go c.read()
+
+	if len(streams) > 0 {
+		if err := c.Subscribe(streams...); err != nil {
+			c.mu.Lock()
+			c.closing = true
+			c.mu.Unlock()
+			c.conn.Close()
+			<-c.done
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Subscribe the streams and wait for the response
+func (c *Conn) Subscribe(streams ...string) error {
+	if _, err := c.request(Subscribe, streams); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, stream := range streams {
+		if !contains(c.streams, stream) {
+			c.streams = append(c.streams, stream)
+		}
+	}
+	return nil
+}
+
+// Unsubscribe the streams and wait for the response
+func (c *Conn) Unsubscribe(streams ...string) error {
+	if _, err := c.request(Unsubscribe, streams); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	kept := c.streams[:0]
+	for _, stream := range c.streams {
+		if !contains(streams, stream) {
+			kept = append(kept, stream)
+		}
+	}
+	c.streams = kept
+	return nil
+}
+
+// Get the streams subscribed on the connection from the server
+func (c *Conn) ListSubscriptions() ([]string, error) {
+	result, err := c.request(ListSubscriptions, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	streams := []string{}
+	if err := json.Unmarshal(result, &streams); err != nil {
+		return nil, fmt.Errorf("unmarshal subscriptions: %w", err)
+	}
+	return streams, nil
+}
+
+// Get the streams subscribed by this handle
+func (c *Conn) Streams() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.streams...)
+}
+
+// Closed when the connection is closed or fails
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Unsubscribe the streams and close the connection, no handler is called after it returns.
+// It can be called from MsgHandler or ErrHandler, it does not wait for a handler which is running.
+func (c *Conn) Close() {
+	c.once.Do(func() {
+		c.mu.Lock()
+		c.closing = true
+		streams := append([]string{}, c.streams...)
+		c.mu.Unlock()
+
+		if len(streams) > 0 {
+			c.writeJSON(&SubReq{Method: Unsubscribe, Params: streams, Id: nextRequestId()})
+		}
+		c.conn.Close()
+	})
+
+	c.mu.Lock()
+	handling := c.handling
+	c.mu.Unlock()
+	if !handling {
+		<-c.done
+	}
+}
+
+// Send a request and wait for its response
+func (c *Conn) request(method string, params []string) (json.RawMessage, error) {
+	id := nextRequestId()
+	ch := make(chan *SubResp, 1)
+
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		return nil, ErrConnClosed
+	}
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.writeJSON(&SubReq{Method: method, Params: params, Id: id}); err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
+		}
+		return resp.Result, nil
+	case <-c.done:
+		return nil, ErrConnClosed
+	case <-timer.C:
+		return nil, fmt.Errorf("no response to %s request %d in %v", method, id, c.timeout)
+	}
+}
+
+// Read the messages until the connection fails, the responses are routed to their requests
+func (c *Conn) read() {
+	defer close(c.done)
+	for {
+		_, message, err := c.conn.ReadMessage()
+		if err != nil {
+			c.handle(func() { c.handler.ErrHandler(err) })
+			return
+		}
+
+		if resp, ok := parseSubResp(message); ok {
+			c.mu.Lock()
+			ch, ok := c.pending[resp.Id]
+			c.mu.Unlock()
+			if ok {
+				ch <- resp
+			}
+			continue
+		}
+		if !c.handle(func() { c.handler.MsgHandler(message) }) {
+			return
+		}
+	}
+}
+
+// Run a handler unless the connection is closing, false is returned when it is closing
+func (c *Conn) handle(handler func()) bool {
+	c.mu.Lock()
+	if c.closing {
+		c.mu.Unlock()
+		return false
+	}
+	c.handling = true
+	c.mu.Unlock()
+
+	handler()
+
+	c.mu.Lock()
+	c.handling = false
+	c.mu.Unlock()
+	return true
+}
+
+func (c *Conn) writeJSON(req *SubReq) error {
+	buff, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	return c.write(websocket.TextMessage, buff)
+}
+
+func (c *Conn) write(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
+func contains(streams []string, stream string) bool {
+	for _, s := range streams {
+		if s == stream {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/h9896/bingo/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// A server which responds to the requests like binance, the streams containing "invalid" are rejected.
+// Every response is followed by the data when it is set.
+func subscriptions(data []byte) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		streams := []string{}
+		for {
+			_, message, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			req := &SubReq{}
+			if err := json.Unmarshal(message, req); err != nil {
+				return
+			}
+
+			resp := fmt.Sprintf(`{"result":null,"id":%d}`, req.Id)
+			switch {
+			case strings.Contains(strings.Join(req.Params, ","), "invalid"):
+				resp = fmt.Sprintf(`{"error":{"code":2,"msg":"Invalid request: unknown stream"},"id":%d}`, req.Id)
+			case req.Method == Subscribe:
+				streams = append(streams, req.Params...)
+			case req.Method == Unsubscribe:
+				kept := []string{}
+				for _, stream := range streams {
+					if !contains(req.Params, stream) {
+						kept = append(kept, stream)
+					}
+				}
+				streams = kept
+			case req.Method == ListSubscriptions:
+				result, _ := json.Marshal(streams)
+				resp = fmt.Sprintf(`{"result":%s,"id":%d}`, result, req.Id)
+			}
+
+			if err := c.WriteMessage(websocket.TextMessage, []byte(resp)); err != nil {
+				return
+			}
+			if data == nil {
+				continue
+			}
+			if err := c.WriteMessage(websocket.TextMessage, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+type countClient struct {
+	testClient
+	mu       sync.Mutex
+	messages int
+}
+
+func (c *countClient) MsgHandler(msg []byte) {
+	c.mu.Lock()
+	c.messages++
+	c.mu.Unlock()
+	c.testClient.MsgHandler(msg)
+}
+
+func (c *countClient) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.messages
+}
+
+func TestConn(t *testing.T) {
+	data := []byte(`{"e": "aggTrade", "s": "BTCUSD_PERP", "p": "30000.2", "q": "234"}`)
+	s := httptest.NewServer(http.HandlerFunc(subscriptions(data)))
+	defer s.Close()
+
+	client := &countClient{testClient: testClient{aggregate: &events.AggregateMsg{}, t: t}}
+	cfg := WsConfig{Name: s.URL, Symbols: []string{"btcusd_perp"}, Service: "aggTrade"}
+	conn, err := Connect(client, cfg)
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"btcusd_perp@aggTrade"}, conn.Streams())
+
+	assert.Nil(t, conn.Subscribe("ethusd_perp@aggTrade", "bnbusd_perp@aggTrade"))
+	assert.Nil(t, conn.Unsubscribe("btcusd_perp@aggTrade"))
+	streams, err := conn.ListSubscriptions()
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"ethusd_perp@aggTrade", "bnbusd_perp@aggTrade"}, streams)
+	assert.EqualValues(t, streams, conn.Streams())
+
+	// A rejected request is returned as an error and the streams are unchanged
+	err = conn.Subscribe("invalid@aggTrade")
+	subErr := &SubscriptionError{}
+	assert.True(t, errors.As(err, &subErr))
+	assert.EqualValues(t, 2, subErr.Code)
+	assert.EqualValues(t, "Invalid request: unknown stream", subErr.Message)
+	assert.EqualValues(t, streams, conn.Streams())
+
+	// Only the data reaches MsgHandler, the responses are filtered out
+	assert.Eventually(t, func() bool {
+		return client.count() == 5
+	}, time.Second, time.Millisecond)
+
+	conn.Close()
+	conn.Close()
+	<-conn.Done()
+	assert.ErrorIs(t, conn.Subscribe("btcusd_perp@aggTrade"), ErrConnClosed)
+}
+
+func TestConnectRejected(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(subscriptions(nil)))
+	defer s.Close()
+
+	cfg := WsConfig{Name: s.URL, Symbols: []string{"invalid"}, Service: "aggTrade"}
+	_, err := Connect(newTestClient(t), cfg)
+	subErr := &SubscriptionError{}
+	assert.True(t, errors.As(err, &subErr))
+}
@@ -1,11 +1,21 @@
 package ws
 
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/h9896/bingo/profile"
+)
+
 const (
 	Ws_coin_futures = "dstream.binance.com/ws"
 
 	Ws_format   = "%s://%s"
 	Subscribe   = "SUBSCRIBE"
 	Unsubscribe = "UNSUBSCRIBE"
+
+	ListSubscriptions = "LIST_SUBSCRIPTIONS"
 )
 
 // WsClient - an interface of websocket client
@@ -22,6 +32,32 @@ type WsConfig struct {
 	Name    string
 	Symbols []string
 	Service string
+	// Host of the streams, Ws_coin_futures is used when it is empty
+	BaseURL string
+}
+
+// Create a config of a market's streams from a profile, e.g. NewWsConfig(profile.Testnet.CoinM, ...)
+func NewWsConfig(endpoints profile.Endpoints, name string, symbols []string, service string) WsConfig {
+	return WsConfig{
+		UseSSL:  endpoints.UseSSL,
+		Name:    name,
+		Symbols: symbols,
+		Service: service,
+		BaseURL: endpoints.WsBaseURL,
+	}
+}
+
+// Get the endpoint of the raw streams, e.g. wss://dstream.binance.com/ws
+func (cfg WsConfig) GetBaseEndpoint() string {
+	base := Ws_coin_futures
+	if cfg.BaseURL != "" {
+		base = fmt.Sprintf("%s/ws", cfg.BaseURL)
+	}
+
+	if cfg.UseSSL {
+		return fmt.Sprintf(Ws_format, "wss", base)
+	}
+	return fmt.Sprintf(Ws_format, "ws", base)
 }
 
 type SubReq struct {
@@ -29,3 +65,36 @@ type SubReq struct {
 	Params []string `json:"params,omitempty"`
 	Id     int64    `json:"id,omitempty"`
 }
+
+// SubResp is the response of a request sent on a connection, e.g. {"result":null,"id":1}
+type SubResp struct {
+	Result json.RawMessage    `json:"result,omitempty"`
+	Error  *SubscriptionError `json:"error,omitempty"`
+	Id     int64              `json:"id,omitempty"`
+}
+
+// SubscriptionError is returned by the server for a rejected request, e.g. an invalid stream name
+type SubscriptionError struct {
+	Code    int64  `json:"code"`
+	Message string `json:"msg"`
+	Id      int64  `json:"-"`
+}
+
+func (e *SubscriptionError) Error() string {
+	return fmt.Sprintf("<SubscriptionError> id=%d, code=%d, msg=%s", e.Id, e.Code, e.Message)
+}
+
+// Parse the response of a request, a stream event is not a response
+func parseSubResp(msg []byte) (*SubResp, bool) {
+	if !bytes.Contains(msg, []byte(`"id"`)) {
+		return nil, false
+	}
+	resp := &SubResp{}
+	if err := json.Unmarshal(msg, resp); err != nil || resp.Id == 0 || (resp.Result == nil && resp.Error == nil) {
+		return nil, false
+	}
+	if resp.Error != nil {
+		resp.Error.Id = resp.Id
+	}
+	return resp, true
+}
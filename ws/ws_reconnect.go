@@ -0,0 +1,346 @@
+package ws
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	DefaultMinBackoff = time.Second
+	DefaultMaxBackoff = time.Minute
+	// Binance closes a connection after 24 hours, it is replaced before that
+	DefaultMaxConnectionAge = 23 * time.Hour
+)
+
+// State of a reconnecting connection
+type State int
+
+const (
+	StateConnecting State = iota
+	StateConnected
+	StateReconnecting
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateReconnecting:
+		return "reconnecting"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+type reconnectConfig struct {
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	maxAge       time.Duration
+	stateHandler func(state State)
+}
+
+type ReconnectOption func(cfg *reconnectConfig)
+
+// Wait from min up to max between the attempts to reconnect, the delay doubles after every failure.
+// The delay is kept across the reconnections and only reset once a connection stays open for max.
+func WithBackoff(min, max time.Duration) ReconnectOption {
+	return func(cfg *reconnectConfig) {
+		cfg.minBackoff = min
+		cfg.maxBackoff = max
+	}
+}
+
+// Replace the connection after it has been open for the duration
+func WithMaxConnectionAge(d time.Duration) ReconnectOption {
+	return func(cfg *reconnectConfig) {
+		cfg.maxAge = d
+	}
+}
+
+// Receive the changes of the state of the connection
+func WithStateHandler(handler func(state State)) ReconnectOption {
+	return func(cfg *reconnectConfig) {
+		cfg.stateHandler = handler
+	}
+}
+
+// ReconnectingConn is a Conn which is reopened with an exponential backoff when it fails
+// and replaced before it reaches the maximum age. The streams subscribed on the current connection
+// are subscribed again on every new one, a change of the streams fails with ErrConnClosed while it reconnects.
+//
+// A replacement is subscribed before the old connection is closed, only the current connection passes its
+// messages to the client. The messages of the old one are dropped as soon as the new subscription is
+// acknowledged, so MsgHandler is never called by two connections at once. The connections are not aligned,
+// an event around the switch can still be repeated or skipped, deduplicate by the ids of the events when it matters.
+type ReconnectingConn struct {
+	client   WsClient
+	endpoint string
+	cfg      reconnectConfig
+	// The delay before the next attempt to reconnect, only used by run
+	delay time.Duration
+
+	// Serializes the changes of the streams with the replacement of the connection
+	subMu sync.Mutex
+	// Serializes the messages of the connections
+	msgMu sync.Mutex
+	mu    sync.Mutex
+	conn  *Conn
+	// The relay of the current connection
+	active *relay
+	// Set while the run goroutine runs a handler, Close does not wait for it then
+	handling bool
+	quit     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Open a connection and subscribe the streams of the config like Connect, the connection is
+// reconnected until Close is called. The error of the first connection or subscription is returned.
+func ConnectReconnecting[T WsClient](client T, cfg WsConfig, opts ...ReconnectOption) (*ReconnectingConn, error) {
+	r := &ReconnectingConn{
+		client:   client,
+		endpoint: client.GetEndpoint(cfg),
+		cfg: reconnectConfig{
+			minBackoff:   DefaultMinBackoff,
+			maxBackoff:   DefaultMaxBackoff,
+			maxAge:       DefaultMaxConnectionAge,
+			stateHandler: func(state State) {},
+		},
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&r.cfg)
+	}
+	r.delay = r.cfg.minBackoff
+
+	r.cfg.stateHandler(StateConnecting)
+	r.active = &relay{WsClient: client, r: r}
+	conn, err := connect(r.active, r.endpoint, client.GetServices(cfg))
+	if err != nil {
+		r.cfg.stateHandler(StateClosed)
+		return nil, err
+	}
+	r.conn = conn
+	r.cfg.stateHandler(StateConnected)
+
+	go r.run(conn)
+	return r, nil
+}
+
+// Subscribe the streams like StartSubscribe, the connection is reopened with an exponential backoff
+// when it fails and replaced before it reaches the maximum age. The streams are subscribed again on
+// every new connection. It waits for the response of the first subscription, the error of the first
+// connection is returned and a rejected subscription is returned as a SubscriptionError.
+func StartReconnectingSubscribe[T WsClient](client T, cfg WsConfig, opts ...ReconnectOption) (cleanup func(), err error) {
+	conn, err := ConnectReconnecting(client, cfg, opts...)
+	if err != nil {
+		return func() {}, err
+	}
+	return conn.Close, nil
+}
+
+// Subscribe the streams on the current connection and wait for the response
+func (r *ReconnectingConn) Subscribe(streams ...string) error {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	return r.current().Subscribe(streams...)
+}
+
+// Unsubscribe the streams on the current connection and wait for the response
+func (r *ReconnectingConn) Unsubscribe(streams ...string) error {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+	return r.current().Unsubscribe(streams...)
+}
+
+// Get the streams subscribed on the current connection from the server
+func (r *ReconnectingConn) ListSubscriptions() ([]string, error) {
+	return r.current().ListSubscriptions()
+}
+
+// Get the streams which are subscribed on every new connection
+func (r *ReconnectingConn) Streams() []string {
+	return r.current().Streams()
+}
+
+// Unsubscribe the streams, close the connection and stop reconnecting.
+// It can be called from the handlers of the client and the state handler, it does not wait for a handler which is running.
+func (r *ReconnectingConn) Close() {
+	r.once.Do(func() {
+		r.mu.Lock()
+		close(r.quit)
+		conn := r.conn
+		r.mu.Unlock()
+
+		conn.Close()
+	})
+
+	r.mu.Lock()
+	handling := r.handling
+	r.mu.Unlock()
+	if !handling {
+		<-r.done
+	}
+}
+
+func (r *ReconnectingConn) current() *Conn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.conn
+}
+
+func (r *ReconnectingConn) run(conn *Conn) {
+	defer close(r.done)
+	defer r.handle(func() { r.cfg.stateHandler(StateClosed) })
+
+	connected := time.Now()
+	age := time.NewTimer(r.cfg.maxAge)
+	defer age.Stop()
+	for {
+		select {
+		case <-r.quit:
+			return
+		case <-age.C:
+			// The new connection is open before the old one is closed, it is dialed again after a failure
+			next, err := r.redial()
+			if err != nil {
+				if r.closed() {
+					return
+				}
+				r.handle(func() { r.client.ErrHandler(err) })
+				age.Reset(r.cfg.minBackoff)
+				continue
+			}
+			conn.Close()
+			conn, connected = next, time.Now()
+			age.Reset(r.cfg.maxAge)
+		case <-conn.Done():
+			// The failure is reported to ErrHandler by the connection
+			if r.closed() {
+				return
+			}
+			// Only a connection which stayed open resets the backoff, a flapping one keeps backing off
+			wait := time.Since(connected) < r.cfg.maxBackoff
+			if !wait {
+				r.delay = r.cfg.minBackoff
+			}
+			r.handle(func() { r.cfg.stateHandler(StateReconnecting) })
+			if conn = r.reconnect(wait); conn == nil {
+				return
+			}
+			r.handle(func() { r.cfg.stateHandler(StateConnected) })
+
+			connected = time.Now()
+			if !age.Stop() {
+				select {
+				case <-age.C:
+				default:
+				}
+			}
+			age.Reset(r.cfg.maxAge)
+		}
+	}
+}
+
+// Dial until a connection is open, waiting for the backoff first when wait is set.
+// Nil is returned when the connection is closed.
+func (r *ReconnectingConn) reconnect(wait bool) *Conn {
+	for {
+		if wait {
+			select {
+			case <-r.quit:
+				return nil
+			case <-time.After(r.delay):
+			}
+			if r.delay *= 2; r.delay > r.cfg.maxBackoff {
+				r.delay = r.cfg.maxBackoff
+			}
+		}
+
+		conn, err := r.redial()
+		if err == nil {
+			return conn
+		}
+		if r.closed() {
+			return nil
+		}
+		r.handle(func() { r.client.ErrHandler(err) })
+		wait = true
+	}
+}
+
+// Open a connection with the streams of the current one and make it the current one
+func (r *ReconnectingConn) redial() (*Conn, error) {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+
+	next := &relay{WsClient: r.client, r: r}
+	conn, err := connect(next, r.endpoint, r.current().Streams())
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	if r.closed() {
+		r.mu.Unlock()
+		conn.Close()
+		return nil, ErrConnClosed
+	}
+	r.conn, r.active = conn, next
+	r.mu.Unlock()
+	return conn, nil
+}
+
+// Run a handler on the run goroutine
+func (r *ReconnectingConn) handle(handler func()) {
+	r.mu.Lock()
+	r.handling = true
+	r.mu.Unlock()
+
+	handler()
+
+	r.mu.Lock()
+	r.handling = false
+	r.mu.Unlock()
+}
+
+func (r *ReconnectingConn) isActive(l *relay) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.active == l
+}
+
+func (r *ReconnectingConn) closed() bool {
+	select {
+	case <-r.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// relay passes the messages and the errors of one connection to the client while it is the current connection
+type relay struct {
+	WsClient
+	r *ReconnectingConn
+}
+
+func (l *relay) MsgHandler(msg []byte) {
+	l.r.msgMu.Lock()
+	defer l.r.msgMu.Unlock()
+	if l.r.isActive(l) {
+		l.WsClient.MsgHandler(msg)
+	}
+}
+
+func (l *relay) ErrHandler(err error) {
+	if l.r.isActive(l) {
+		l.WsClient.ErrHandler(err)
+	}
+}
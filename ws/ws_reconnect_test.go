@@ -0,0 +1,339 @@
+package ws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/h9896/bingo/events"
+	"github.com/stretchr/testify/assert"
+)
+
+// Acknowledge every request and send the data after it, the connection is closed after the number of replies
+func dropAfter(replies int, data []byte, requests chan<- *SubReq) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for i := 0; replies <= 0 || i < replies; i++ {
+			mt, message, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			req := &SubReq{}
+			if err := json.Unmarshal(message, req); err != nil {
+				return
+			}
+			requests <- req
+			if err := c.WriteMessage(mt, []byte(fmt.Sprintf(`{"result":null,"id":%d}`, req.Id))); err != nil {
+				return
+			}
+			if err := c.WriteMessage(mt, data); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Count the connections of a handler and the most connections open at the same time
+type connCounter struct {
+	open, max, total int32
+}
+
+func (c *connCounter) wrap(handler func(w http.ResponseWriter, r *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&c.total, 1)
+		open := atomic.AddInt32(&c.open, 1)
+		for {
+			max := atomic.LoadInt32(&c.max)
+			if open <= max || atomic.CompareAndSwapInt32(&c.max, max, open) {
+				break
+			}
+		}
+		defer atomic.AddInt32(&c.open, -1)
+		handler(w, r)
+	}
+}
+
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []State
+}
+
+func (r *stateRecorder) handle(state State) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, state)
+}
+
+func (r *stateRecorder) get() []State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]State{}, r.states...)
+}
+
+func TestStartReconnectingSubscribe(t *testing.T) {
+	data := []byte(`{"e": "aggTrade", "s": "BTCUSD_PERP", "p": "30000.2", "q": "234"}`)
+	requests := make(chan *SubReq, 10)
+	s := httptest.NewServer(http.HandlerFunc(dropAfter(1, data, requests)))
+	defer s.Close()
+
+	states := &stateRecorder{}
+	cfg := WsConfig{Name: s.URL, Symbols: []string{"btcusd_perp"}, Service: "aggTrade"}
+	cleanup, err := StartReconnectingSubscribe(newTestClient(t), cfg,
+		WithBackoff(10*time.Millisecond, 50*time.Millisecond), WithStateHandler(states.handle))
+	assert.Nil(t, err)
+
+	// The streams are subscribed again after the connection is dropped
+	for i := 0; i < 2; i++ {
+		select {
+		case req := <-requests:
+			assert.EqualValues(t, Subscribe, req.Method)
+			assert.EqualValues(t, []string{"btcusd_perp@aggTrade"}, req.Params)
+		case <-time.After(time.Second):
+			t.Fatal("the streams are not subscribed")
+		}
+	}
+	// The new connection is connected once its subscription is acknowledged
+	assert.Eventually(t, func() bool {
+		return len(states.get()) >= 4
+	}, time.Second, time.Millisecond)
+
+	cleanup()
+	cleanup()
+	got := states.get()
+	assert.EqualValues(t, []State{StateConnecting, StateConnected, StateReconnecting, StateConnected}, got[:4])
+	assert.EqualValues(t, StateClosed, got[len(got)-1])
+}
+
+func TestStartReconnectingSubscribeMaxAge(t *testing.T) {
+	data := []byte(`{"e": "aggTrade", "s": "BTCUSD_PERP", "p": "30000.2", "q": "234"}`)
+	requests := make(chan *SubReq, 10)
+	counter := &connCounter{}
+	s := httptest.NewServer(counter.wrap(dropAfter(0, data, requests)))
+	defer s.Close()
+
+	client := &errClient{testClient: testClient{aggregate: &events.AggregateMsg{}, t: t}}
+	cfg := WsConfig{Name: s.URL, Symbols: []string{"btcusd_perp"}, Service: "aggTrade"}
+	cleanup, err := StartReconnectingSubscribe(client, cfg, WithMaxConnectionAge(50*time.Millisecond))
+	assert.Nil(t, err)
+
+	// The connection is replaced without an error
+	for i := 0; i < 2; i++ {
+		select {
+		case req := <-requests:
+			assert.EqualValues(t, Subscribe, req.Method)
+		case <-time.After(time.Second):
+			t.Fatal("the connection is not replaced")
+		}
+	}
+	cleanup()
+	assert.Zero(t, client.errors)
+	// The new connection is open before the old one is closed
+	assert.EqualValues(t, 2, atomic.LoadInt32(&counter.max))
+}
+
+func TestStartReconnectingSubscribeBackoff(t *testing.T) {
+	data := []byte(`{"e": "aggTrade", "s": "BTCUSD_PERP", "p": "30000.2", "q": "234"}`)
+	counter := &connCounter{}
+	s := httptest.NewServer(counter.wrap(dropAfter(1, data, make(chan *SubReq, 100))))
+	defer s.Close()
+
+	client := &errClient{testClient: testClient{aggregate: &events.AggregateMsg{}, t: t}}
+	cfg := WsConfig{Name: s.URL, Symbols: []string{"btcusd_perp"}, Service: "aggTrade"}
+	cleanup, err := StartReconnectingSubscribe(client, cfg, WithBackoff(20*time.Millisecond, time.Second))
+	assert.Nil(t, err)
+
+	// A connection which is dropped at once does not reset the backoff,
+	// the delays of 20, 40, 80 and 160ms leave room for about 5 connections
+	time.Sleep(300 * time.Millisecond)
+	cleanup()
+	assert.LessOrEqual(t, atomic.LoadInt32(&counter.total), int32(6))
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&counter.total), int32(3))
+}
+
+func TestStartReconnectingSubscribeDialError(t *testing.T) {
+	states := &stateRecorder{}
+	cleanup, err := StartReconnectingSubscribe(newTestClient(t), WsConfig{Name: "http://127.0.0.1:1"}, WithStateHandler(states.handle))
+	assert.NotNil(t, err)
+	cleanup()
+	assert.EqualValues(t, []State{StateConnecting, StateClosed}, states.get())
+}
+
+type errClient struct {
+	testClient
+	mu     sync.Mutex
+	errors int
+}
+
+func (c *errClient) ErrHandler(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors++
+}
+
+func TestStartReconnectingSubscribeRejected(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(subscriptions(nil)))
+	defer s.Close()
+
+	states := &stateRecorder{}
+	client := &errClient{testClient: testClient{aggregate: &events.AggregateMsg{}, t: t}}
+	cfg := WsConfig{Name: s.URL, Symbols: []string{"invalid"}, Service: "aggTrade"}
+	cleanup, err := StartReconnectingSubscribe(client, cfg, WithStateHandler(states.handle))
+
+	// The rejection of the first subscription is returned and nothing is reconnected
+	subErr := &SubscriptionError{}
+	assert.True(t, errors.As(err, &subErr))
+	assert.EqualValues(t, 2, subErr.Code)
+	cleanup()
+	assert.EqualValues(t, []State{StateConnecting, StateClosed}, states.get())
+	assert.Zero(t, client.errors)
+}
+
+func TestReconnectingConn(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(subscriptions(nil)))
+	defer s.Close()
+
+	states := &stateRecorder{}
+	client := &errClient{testClient: testClient{aggregate: &events.AggregateMsg{}, t: t}}
+	cfg := WsConfig{Name: s.URL, Symbols: []string{"btcusd_perp"}, Service: "aggTrade"}
+	conn, err := ConnectReconnecting(client, cfg, WithBackoff(10*time.Millisecond, 50*time.Millisecond), WithStateHandler(states.handle))
+	assert.Nil(t, err)
+
+	assert.Nil(t, conn.Subscribe("ethusd_perp@aggTrade"))
+	assert.Nil(t, conn.Unsubscribe("btcusd_perp@aggTrade"))
+	assert.EqualValues(t, []string{"ethusd_perp@aggTrade"}, conn.Streams())
+
+	// The current streams are subscribed again after the connection is dropped
+	dropped := conn.current()
+	dropped.conn.Close()
+	assert.Eventually(t, func() bool {
+		return conn.current() != dropped
+	}, time.Second, time.Millisecond)
+	streams, err := conn.ListSubscriptions()
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"ethusd_perp@aggTrade"}, streams)
+	assert.EqualValues(t, streams, conn.Streams())
+
+	conn.Close()
+	conn.Close()
+	assert.ErrorIs(t, conn.Subscribe("btcusd_perp@aggTrade"), ErrConnClosed)
+	assert.EqualValues(t, []State{StateConnecting, StateConnected, StateReconnecting, StateConnected, StateClosed}, states.get())
+}
+
+// Acknowledge every request and send the messages of the connection numbered by the order of the connections
+// from the first subscription on, until the connection is closed
+func sequence(conns *int32) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		id := atomic.AddInt32(conns, 1)
+
+		var mu sync.Mutex
+		subscribed := make(chan struct{})
+		go func() {
+			for seq := 0; ; seq++ {
+				select {
+				case <-subscribed:
+				case <-r.Context().Done():
+					return
+				}
+				mu.Lock()
+				err := c.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"conn":%d,"seq":%d}`, id, seq)))
+				mu.Unlock()
+				if err != nil {
+					return
+				}
+				time.Sleep(100 * time.Microsecond)
+			}
+		}()
+
+		var once sync.Once
+		for {
+			_, message, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			req := &SubReq{}
+			if err := json.Unmarshal(message, req); err != nil {
+				return
+			}
+			mu.Lock()
+			err = c.WriteMessage(websocket.TextMessage, []byte(fmt.Sprintf(`{"result":null,"id":%d}`, req.Id)))
+			mu.Unlock()
+			if err != nil {
+				return
+			}
+			once.Do(func() { close(subscribed) })
+		}
+	}
+}
+
+// Record the connection of every message and whether MsgHandler is entered concurrently
+type seqClient struct {
+	testClient
+	inside     int32
+	concurrent int32
+	mu         sync.Mutex
+	conns      []int
+}
+
+func (c *seqClient) MsgHandler(msg []byte) {
+	if atomic.AddInt32(&c.inside, 1) > 1 {
+		atomic.AddInt32(&c.concurrent, 1)
+	}
+	defer atomic.AddInt32(&c.inside, -1)
+
+	m := struct {
+		Conn int `json:"conn"`
+	}{}
+	assert.Nil(c.t, json.Unmarshal(msg, &m))
+	time.Sleep(50 * time.Microsecond)
+	c.mu.Lock()
+	c.conns = append(c.conns, m.Conn)
+	c.mu.Unlock()
+}
+
+func (c *seqClient) get() []int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int{}, c.conns...)
+}
+
+func TestStartReconnectingSubscribeMaxAgeMessages(t *testing.T) {
+	var conns int32
+	s := httptest.NewServer(http.HandlerFunc(sequence(&conns)))
+	defer s.Close()
+
+	client := &seqClient{testClient: testClient{t: t}}
+	cfg := WsConfig{Name: s.URL, Symbols: []string{"btcusd_perp"}, Service: "aggTrade"}
+	cleanup, err := StartReconnectingSubscribe(client, cfg, WithMaxConnectionAge(30*time.Millisecond))
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&conns) >= 5
+	}, 2*time.Second, time.Millisecond)
+	cleanup()
+
+	// The messages of a connection stop once the next one delivers, one connection at a time
+	got := client.get()
+	assert.NotEmpty(t, got)
+	for i := 1; i < len(got); i++ {
+		if got[i] < got[i-1] {
+			t.Fatalf("message of connection %d after connection %d", got[i], got[i-1])
+		}
+	}
+	assert.Greater(t, got[len(got)-1], got[0])
+	assert.Zero(t, atomic.LoadInt32(&client.concurrent))
+}